@@ -0,0 +1,32 @@
+package gotimeout
+
+import "sync"
+
+//entries are constantly recreated under churn, growing a callbacks slice from scratch every time
+//the slices are recycled once an entry fired, the entries themselves are not
+//as CancelFuncs, CallbackRefs and late joiners may still hold on to a fired entry
+//a recycled entry would let such a stale reference cancel, or join, callbacks of whatever timeout reused it
+var callbacksPool = sync.Pool{
+	New: func() interface{} {
+		s := make([]*callbackSlot, 0, 16)
+		return &s
+	},
+}
+
+//slices that grew beyond this are left to the GC, so a single burst does not pin its memory forever
+const maxPooledCallbacks = 4096
+
+func getCallbacks() []*callbackSlot {
+	return *callbacksPool.Get().(*[]*callbackSlot)
+}
+
+//putCallbacks recycles the slice of a fired entry, after all of its callbacks ran
+func putCallbacks(callbacks []*callbackSlot) {
+	if cap(callbacks) == 0 || cap(callbacks) > maxPooledCallbacks {
+		return
+	}
+	//don't keep the callbacks alive through the pool
+	clear(callbacks)
+	callbacks = callbacks[:0]
+	callbacksPool.Put(&callbacks)
+}
@@ -0,0 +1,33 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+//BenchmarkChurn keeps recreating entries, every 10 callbacks the one they join expires and an older one fires
+func BenchmarkChurn(b *testing.B) {
+	to, clock := newFakeTimeout(gotimeout.WithCacheWindow(time.Millisecond))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		to.AfterDuration(100*time.Millisecond, func() {})
+		if i%10 == 9 {
+			clock.Advance(2 * time.Millisecond)
+		}
+	}
+}
+
+//BenchmarkJoinParallel has every goroutine join the entry of the same length, the hot path under contention
+//run it with -cpu to see how it scales with the number of cores
+func BenchmarkJoinParallel(b *testing.B) {
+	to := gotimeout.MustNewTimeout()
+	defer to.Stop()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			to.AfterFunc(1, func() {})
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package gotimeout
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestNextBoundary(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	india := time.FixedZone("IST", 5*3600+1800)
+	for _, c := range []struct {
+		now  time.Time
+		d    time.Duration
+		want time.Time
+	}{
+		{time.Date(2026, 3, 1, 12, 0, 20, 5e8, time.UTC), time.Minute, time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC), time.Minute, time.Date(2026, 3, 1, 12, 2, 0, 0, time.UTC)},
+		//hours and days follow the wall clock of the location, not UTC
+		{time.Date(2026, 3, 1, 10, 20, 0, 0, india), time.Hour, time.Date(2026, 3, 1, 11, 0, 0, 0, india)},
+		{time.Date(2026, 3, 1, 10, 20, 0, 0, india), 24 * time.Hour, time.Date(2026, 3, 2, 0, 0, 0, 0, india)},
+		//2am is skipped in spring, the next hour on the wall clock is 3am
+		{time.Date(2026, 3, 8, 1, 30, 0, 0, newYork), time.Hour, time.Date(2026, 3, 8, 3, 0, 0, 0, newYork)},
+		//1am repeats in autumn, the second 1am is a boundary as well
+		{time.Date(2026, 11, 1, 1, 30, 0, 0, newYork), time.Hour, time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC)},
+		//midnight after a change of offset is counted in the new offset
+		{time.Date(2026, 11, 1, 1, 30, 0, 0, newYork), 24 * time.Hour, time.Date(2026, 11, 2, 0, 0, 0, 0, newYork)},
+		{time.Date(2026, 3, 7, 23, 0, 0, 0, newYork), 24 * time.Hour, time.Date(2026, 3, 8, 0, 0, 0, 0, newYork)},
+		{time.Date(2026, 3, 8, 1, 0, 0, 0, newYork), 24 * time.Hour, time.Date(2026, 3, 9, 0, 0, 0, 0, newYork)},
+	} {
+		if got := nextBoundary(c.now, c.d); !got.Equal(c.want) {
+			t.Errorf("nextBoundary(%v, %v) = %v, want %v", c.now, c.d, got, c.want)
+		}
+	}
+}
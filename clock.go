@@ -0,0 +1,38 @@
+package gotimeout
+
+import "time"
+
+// Clock is the source of time used by a Timeout, the real time package is used by default
+// inject a fake implementation to test code using a Timeout without sleeping
+// the Timeout only ever compares times returned by Now with each other, never with time.Now, so the clock is never mixed with another
+// Now should be monotonic like time.Now, whose monotonic reading keeps wall clock steps out of every comparison
+// with a Now that steps either way no entry is joined across the step, it counts as expired and callbacks get a fresh entry rather than fire early
+// the real AfterFunc runs f on a goroutine of its own, as do both timing wheels, so each fired entry runs its batch apart from the others
+// and a callback that blocks or panics never holds up another entry, an AfterFunc that calls f on its caller, like a fake clock, runs it there
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is the part of *time.Timer a Timeout depends on
+type Timer interface {
+	Stop() bool
+}
+
+//stopFunc adapts the stop func returned by a TimerFunc to a Timer
+type stopFunc func()
+
+func (s stopFunc) Stop() bool {
+	s()
+	return true
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
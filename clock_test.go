@@ -0,0 +1,46 @@
+package gotimeout_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+	"github.com/asynkron/gotimeout/gotimeouttest"
+)
+
+//steppingClock has timers that follow the FakeClock, but a Now that is off by step, like a wall clock stepped by NTP
+type steppingClock struct {
+	*gotimeouttest.FakeClock
+	step atomic.Int64
+}
+
+func (c *steppingClock) Now() time.Time {
+	return c.FakeClock.Now().Add(time.Duration(c.step.Load()))
+}
+
+func TestClockStep(t *testing.T) {
+	for _, step := range []time.Duration{-time.Hour, time.Hour} {
+		clock := &steppingClock{FakeClock: gotimeouttest.NewFakeClock(time.Unix(0, 0))}
+		to := gotimeout.MustNewTimeout(gotimeout.WithClock(clock))
+		var first, second atomic.Bool
+		to.AfterFunc(1, func() { first.Store(true) })
+		clock.Advance(400 * time.Millisecond)
+		clock.step.Store(int64(step))
+		clock.Advance(500 * time.Millisecond)
+		//the first entry is 900ms old, joining it would fire the callback 900ms early
+		to.AfterFunc(1, func() { second.Store(true) })
+
+		clock.Advance(100 * time.Millisecond)
+		if !first.Load() {
+			t.Fatalf("step %v: the first callback did not fire on time", step)
+		}
+		if second.Load() {
+			t.Fatalf("step %v: the second callback joined an entry across the clock step and fired early", step)
+		}
+		clock.Advance(900 * time.Millisecond)
+		if !second.Load() {
+			t.Fatalf("step %v: the second callback did not fire", step)
+		}
+	}
+}
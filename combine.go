@@ -0,0 +1,75 @@
+package gotimeout
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+func FirstOf(callback TimeoutCallback, seconds ...int) CancelFunc {
+	return defaultTimeout().FirstOf(callback, seconds...)
+}
+
+func AllOf(callback TimeoutCallback, seconds ...int) CancelFunc {
+	return defaultTimeout().AllOf(callback, seconds...)
+}
+
+// FirstOf schedules the callback for each of the lengths in seconds and runs it once, when the first of them fires
+// the others are cancelled then, cancelling the returned CancelFunc cancels all of them, with no lengths the callback never runs
+func (t *Timeout) FirstOf(callback TimeoutCallback, seconds ...int) CancelFunc {
+	var done atomic.Bool
+	var mu sync.Mutex
+	var cancels []CancelFunc
+	cancelAll := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, cancel := range cancels {
+			cancel()
+		}
+		cancels = nil
+	}
+
+	//the lock keeps a child that fires right away from cancelling its siblings before they are all scheduled
+	mu.Lock()
+	for _, s := range seconds {
+		cancels = append(cancels, t.scheduleOrReport(secondsToDuration(s), &callbackSlot{callback: func() {
+			if done.CompareAndSwap(false, true) {
+				cancelAll()
+				callback()
+			}
+		}}))
+	}
+	mu.Unlock()
+	return func() {
+		if done.CompareAndSwap(false, true) {
+			cancelAll()
+		}
+	}
+}
+
+// AllOf schedules a countdown for each of the lengths in seconds and runs the callback once all of them fired
+// with CacheWindow a longer length may fire before a shorter one, the callback still waits for every one of them
+// cancelling the returned CancelFunc cancels all of them, with no lengths the callback runs right away like AfterFunc(0)
+// a length that could not be scheduled never fires, so the callback does not run either
+func (t *Timeout) AllOf(callback TimeoutCallback, seconds ...int) CancelFunc {
+	if len(seconds) == 0 {
+		return t.scheduleOrReport(0, &callbackSlot{callback: callback})
+	}
+	var done atomic.Bool
+	var remaining atomic.Int64
+	remaining.Store(int64(len(seconds)))
+	cancels := make([]CancelFunc, len(seconds))
+	for i, s := range seconds {
+		cancels[i] = t.scheduleOrReport(secondsToDuration(s), &callbackSlot{callback: func() {
+			if remaining.Add(-1) == 0 && done.CompareAndSwap(false, true) {
+				callback()
+			}
+		}})
+	}
+	return func() {
+		if done.CompareAndSwap(false, true) {
+			for _, cancel := range cancels {
+				cancel()
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFirstOf(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var firedAt []time.Duration
+	start := clock.Now()
+	to.FirstOf(func() { firedAt = append(firedAt, clock.Now().Sub(start)) }, 3, 1, 2, 1)
+	clock.Advance(5 * time.Second)
+	if len(firedAt) != 1 || firedAt[0] != time.Second {
+		t.Fatalf("fired at %v, want once after 1s", firedAt)
+	}
+	if s := to.Stats(); s.Pending != 0 || s.ActiveEntries != 0 {
+		t.Fatalf("Stats %+v, want the longer lengths cancelled", s)
+	}
+
+	fired := 0
+	cancel := to.FirstOf(func() { fired++ }, 1, 2)
+	cancel()
+	clock.Advance(5 * time.Second)
+	if fired != 0 {
+		t.Fatalf("cancelled FirstOf fired %d times", fired)
+	}
+}
+
+func TestAllOf(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var firedAt []time.Duration
+	start := clock.Now()
+	to.AllOf(func() { firedAt = append(firedAt, clock.Now().Sub(start)) }, 2, 3, 1, 3)
+	clock.Advance(2 * time.Second)
+	if len(firedAt) != 0 {
+		t.Fatalf("fired at %v before the longest length", firedAt)
+	}
+	clock.Advance(3 * time.Second)
+	if len(firedAt) != 1 || firedAt[0] != 3*time.Second {
+		t.Fatalf("fired at %v, want once after 3s", firedAt)
+	}
+
+	fired := 0
+	cancel := to.AllOf(func() { fired++ }, 1, 2)
+	clock.Advance(time.Second)
+	cancel()
+	clock.Advance(5 * time.Second)
+	if fired != 0 {
+		t.Fatalf("cancelled AllOf fired %d times", fired)
+	}
+
+	done := make(chan struct{})
+	to.AllOf(func() { close(done) })
+	waitFor(t, done, "AllOf without lengths")
+}
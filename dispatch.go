@@ -0,0 +1,95 @@
+package gotimeout
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+//dispatcher runs fired entries one at a time, earliest deadline first
+//with FairDispatch it takes turns between them instead, running one callback of each ready entry at a time
+//its goroutine only runs while there is something queued, so an idle or stopped Timeout leaks none
+type dispatcher struct {
+	mu      sync.Mutex
+	queue   batches
+	ready   []func() bool //entries taking turns with FairDispatch, each call runs one callback and tells if any are left
+	seq     uint64        //breaks ties between equal deadlines in the order they were submitted
+	running bool
+}
+
+type batch struct {
+	deadline time.Time
+	seq      uint64
+	run      func()
+}
+
+type batches []batch
+
+func (b batches) Len() int { return len(b) }
+func (b batches) Less(i, j int) bool {
+	if !b[i].deadline.Equal(b[j].deadline) {
+		return b[i].deadline.Before(b[j].deadline)
+	}
+	return b[i].seq < b[j].seq
+}
+func (b batches) Swap(i, j int)       { b[i], b[j] = b[j], b[i] }
+func (b *batches) Push(x interface{}) { *b = append(*b, x.(batch)) }
+func (b *batches) Pop() interface{} {
+	old := *b
+	last := old[len(old)-1]
+	old[len(old)-1] = batch{}
+	*b = old[:len(old)-1]
+	return last
+}
+
+func (d *dispatcher) submit(deadline time.Time, run func()) {
+	d.mu.Lock()
+	d.seq++
+	heap.Push(&d.queue, batch{deadline: deadline, seq: d.seq, run: run})
+	d.start()
+}
+
+//submitFair queues an entry for FairDispatch, it takes its turn after the entries that are ready already
+func (d *dispatcher) submitFair(step func() bool) {
+	d.mu.Lock()
+	d.ready = append(d.ready, step)
+	d.start()
+}
+
+//start starts the loop unless it is running, it is called with the lock held and releases it
+func (d *dispatcher) start() {
+	if d.running {
+		d.mu.Unlock()
+		return
+	}
+	d.running = true
+	d.mu.Unlock()
+	go d.loop()
+}
+
+func (d *dispatcher) loop() {
+	for {
+		d.mu.Lock()
+		if d.queue.Len() > 0 {
+			b := heap.Pop(&d.queue).(batch)
+			d.mu.Unlock()
+			b.run()
+			continue
+		}
+		if len(d.ready) == 0 {
+			d.running = false
+			d.mu.Unlock()
+			return
+		}
+		step := d.ready[0]
+		d.ready[0] = nil
+		d.ready = d.ready[1:]
+		d.mu.Unlock()
+		if step() {
+			//back of the line, behind the entries that were ready meanwhile
+			d.mu.Lock()
+			d.ready = append(d.ready, step)
+			d.mu.Unlock()
+		}
+	}
+}
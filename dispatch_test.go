@@ -0,0 +1,46 @@
+package gotimeout_test
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestFairDispatch(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithFairDispatch())
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var ran []string
+	var wg sync.WaitGroup
+	record := func(name string) gotimeout.TimeoutCallback {
+		wg.Add(1)
+		return func() {
+			mu.Lock()
+			ran = append(ran, name)
+			mu.Unlock()
+			wg.Done()
+		}
+	}
+	//the first callback holds up the dispatcher until both entries are ready
+	wg.Add(1)
+	to.AfterFunc(1, func() {
+		<-release
+		mu.Lock()
+		ran = append(ran, "a1")
+		mu.Unlock()
+		wg.Done()
+	})
+	to.AfterFunc(1, record("a2"))
+	to.AfterFunc(1, record("a3"))
+	to.AfterFunc(2, record("b1"))
+	to.AfterFunc(2, record("b2"))
+	clock.Advance(3 * time.Second)
+	close(release)
+	wg.Wait()
+	if want := []string{"a1", "b1", "a2", "b2", "a3"}; !slices.Equal(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+}
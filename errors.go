@@ -0,0 +1,36 @@
+package gotimeout
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrStopped is returned when scheduling on a Timeout that was stopped
+var ErrStopped = errors.New("gotimeout: timeout is stopped")
+
+// ErrNegativeTimeout is returned by TryAfterFunc for a negative timeout, usually the result of an underflowing computation
+var ErrNegativeTimeout = errors.New("gotimeout: negative timeout")
+
+// ErrTooManyPending is returned when scheduling would exceed MaxPendingCallbacks
+var ErrTooManyPending = errors.New("gotimeout: too many pending callbacks")
+
+// ErrOutOfRange is returned for a timeout beyond MaxSeconds when Fallback is FallbackError
+var ErrOutOfRange = errors.New("gotimeout: timeout beyond the cached range")
+
+// ErrInvalidConfig is wrapped by the errors NewTimeout and Validate return for options that do not make sense
+var ErrInvalidConfig = errors.New("gotimeout: invalid configuration")
+
+// ErrReentrant is returned with Strict when a callback schedules on the Timeout it fired from
+var ErrReentrant = errors.New("gotimeout: scheduling from within a callback")
+
+// ErrCallbackTimeout is reported to the ErrorHandler when a callback is abandoned after exceeding CallbackTimeout
+var ErrCallbackTimeout = errors.New("gotimeout: callback exceeded its timeout")
+
+// PanicError is reported to the ErrorHandler when a callback panics
+type PanicError struct {
+	Value interface{} //the value recovered from the panic
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("gotimeout: callback panicked: %v", e.Value)
+}
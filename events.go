@@ -0,0 +1,38 @@
+package gotimeout
+
+import "time"
+
+//events are buffered so a consumer that is a little slow does not lose any
+const eventsBuffer = 1024
+
+// TimeoutEvent describes an entry that fired
+type TimeoutEvent struct {
+	Timeout   time.Duration //timeout length of the entry
+	Fired     time.Time     //when the entry fired
+	Callbacks int           //callbacks the entry ran
+	IDs       []uint64      //ids of those callbacks with TraceCallbacks, nil otherwise
+}
+
+// Events returns a channel receiving an event every time an entry fires, e.g. for auditing or tracing
+// events are only sent once Events was called, and dropped rather than blocking when the channel is full
+// dropped events are counted in Stats, the channel is never closed
+func (t *Timeout) Events() <-chan TimeoutEvent {
+	t.eventsOnce.Do(func() {
+		events := make(chan TimeoutEvent, eventsBuffer)
+		t.events.Store(&events)
+	})
+	return *t.events.Load()
+}
+
+//emit sends the event of a fired entry without ever blocking the trigger
+func (t *Timeout) emit(d time.Duration, callbacks int, ids []uint64) {
+	events := t.events.Load()
+	if events == nil {
+		return
+	}
+	select {
+	case *events <- TimeoutEvent{Timeout: d, Fired: t.now(), Callbacks: callbacks, IDs: ids}:
+	default:
+		t.stats.droppedEvents.Add(1)
+	}
+}
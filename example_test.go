@@ -0,0 +1,17 @@
+package gotimeout_test
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/asynkron/gotimeout"
+)
+
+//serving the snapshot of a Timeout on a debug endpoint
+func ExampleTimeout_Snapshot() {
+	to := gotimeout.MustNewTimeout()
+	http.HandleFunc("/debug/timeouts", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(to.Snapshot())
+	})
+}
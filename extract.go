@@ -0,0 +1,70 @@
+package gotimeout
+
+import (
+	"slices"
+	"time"
+)
+
+// ExtractedCallback is a callback taken out of a Timeout by Extract, with what it takes to schedule it again
+type ExtractedCallback struct {
+	Callback  TimeoutCallback
+	Timeout   time.Duration //timeout length of the entry the callback waited in
+	Remaining time.Duration //time left until the entry was due to fire, 0 if it already was
+}
+
+func Extract() []ExtractedCallback {
+	return defaultTimeout().Extract()
+}
+
+// Extract removes every pending callback from the Timeout and returns them instead of running them, e.g. to hand them off elsewhere
+// each callback is detached with its entry before the entry's timer is cancelled, so it is returned or fires, never both
+// the callbacks are ordered by when they were due, those of an entry in the order they were scheduled
+// entries that are already firing keep their callbacks, the Timeout stays usable and later callbacks go into fresh entries
+// a callback that received its entry, like AfterFuncAt, gets none when called, as if it ran right away
+func (t *Timeout) Extract() []ExtractedCallback {
+	t.mu.Lock()
+	armed := t.armed
+	t.armed = nil
+	t.stats.activeEntries.Add(-int64(len(armed)))
+	t.mu.Unlock()
+
+	entries := make([]*timeoutEntry, 0, len(armed))
+	for entry := range armed {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b *timeoutEntry) int {
+		return a.deadline.Compare(b.deadline)
+	})
+
+	now := t.now()
+	var extracted []ExtractedCallback
+	for _, entry := range entries {
+		all, ok := entry.detach(false)
+		t.release(entry)
+		if !ok {
+			//firing already, it owns its callbacks
+			continue
+		}
+		callbacks := compact(all)
+		remaining := max(entry.deadline.Sub(now), 0)
+		for _, slot := range callbacks {
+			extracted = append(extracted, ExtractedCallback{Callback: slot.detached(), Timeout: entry.timeout, Remaining: remaining})
+		}
+		t.stats.pendingCallbacks.Add(-int64(len(callbacks)))
+		t.dropped("gotimeout: callback extracted", entry.timeout, callbacks...)
+		putCallbacks(all)
+		t.pending.done()
+		if len(callbacks) > 0 {
+			t.emptied(entry)
+		}
+	}
+	return extracted
+}
+
+//detached returns the callback of a slot taken out of its entry, as a plain TimeoutCallback
+func (s *callbackSlot) detached() TimeoutCallback {
+	if s.signal == nil && s.entryCallback == nil {
+		return s.callback
+	}
+	return func() { s.fire(nil) }
+}
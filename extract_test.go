@@ -0,0 +1,78 @@
+package gotimeout_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExtract(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var ran []int
+	to.AfterFunc(5, func() { ran = append(ran, 5) })
+	to.AfterFunc(2, func() { ran = append(ran, 2) })
+	to.AfterFunc(2, func() { ran = append(ran, 22) })
+	cancel := to.AfterFuncCancellable(3, func() { ran = append(ran, 3) })
+	cancel()
+	signal := make(chan struct{}, 1)
+	to.SignalAfter(4, signal)
+	clock.Advance(time.Second)
+
+	extracted := to.Extract()
+	if len(extracted) != 4 {
+		t.Fatalf("extracted %d callbacks, want the 4 that were not cancelled", len(extracted))
+	}
+	want := []struct{ timeout, remaining time.Duration }{
+		{2 * time.Second, time.Second}, {2 * time.Second, time.Second}, {4 * time.Second, 3 * time.Second}, {5 * time.Second, 4 * time.Second},
+	}
+	for i, e := range extracted {
+		if e.Timeout != want[i].timeout || e.Remaining != want[i].remaining {
+			t.Fatalf("extracted[%d] = %v/%v, want %v/%v", i, e.Timeout, e.Remaining, want[i].timeout, want[i].remaining)
+		}
+	}
+	if s := to.Stats(); s.Pending != 0 || s.ActiveEntries != 0 {
+		t.Fatalf("Stats %+v after Extract", s)
+	}
+	waitFor(t, to.Idle(), "Idle after Extract")
+
+	//the cancelled timers never fire the extracted callbacks
+	clock.Advance(time.Minute)
+	if len(ran) != 0 {
+		t.Fatalf("extracted callbacks fired %v", ran)
+	}
+	for _, e := range extracted {
+		e.Callback()
+	}
+	if len(ran) != 3 || ran[0] != 2 || ran[1] != 22 || ran[2] != 5 || len(signal) != 1 {
+		t.Fatalf("running the extracted callbacks ran %v and signalled %d times", ran, len(signal))
+	}
+
+	//the Timeout stays usable
+	to.AfterFunc(1, func() { ran = append(ran, 1) })
+	clock.Advance(time.Second)
+	if ran[len(ran)-1] != 1 {
+		t.Fatalf("callback after Extract did not fire, ran %v", ran)
+	}
+}
+
+func TestExtractWhileFiring(t *testing.T) {
+	for round := 0; round < 20; round++ {
+		to, clock := newFakeTimeout()
+		const n = 200
+		var fired atomic.Int64
+		for i := 0; i < n; i++ {
+			to.AfterFunc(1+i%3, func() { fired.Add(1) })
+		}
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			clock.Advance(2 * time.Second)
+		}()
+		extracted := to.Extract()
+		<-done
+		clock.Advance(time.Minute)
+		if got := fired.Load() + int64(len(extracted)); got != n {
+			t.Fatalf("round %d: %d fired and %d extracted, want %d in total", round, fired.Load(), len(extracted), n)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package gotimeout
+
+import "sync/atomic"
+
+func AfterFuncFinalize(seconds int, callback func(timedOut bool)) CancelFunc {
+	return defaultTimeout().AfterFuncFinalize(seconds, callback)
+}
+
+// AfterFuncFinalize works like AfterFuncCancellable, but cancelling runs the callback as well, e.g. for cleanup that must happen either way
+// timedOut is true when the timeout fired and false when it was cancelled, the callback runs exactly once whichever comes first
+// a cancel that loses the race against the timeout is a no-op, one that wins runs the callback on the caller's goroutine before returning
+// a callback dropped by Stop, or that could not be scheduled, only runs once cancelled
+func (t *Timeout) AfterFuncFinalize(seconds int, callback func(timedOut bool)) CancelFunc {
+	var done atomic.Bool
+	cancel := t.scheduleOrReport(secondsToDuration(seconds), &callbackSlot{callback: func() {
+		if done.CompareAndSwap(false, true) {
+			callback(true)
+		}
+	}})
+	return func() {
+		if done.CompareAndSwap(false, true) {
+			cancel()
+			callback(false)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package gotimeout_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAfterFuncFinalize(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var results []bool
+	record := func(timedOut bool) { results = append(results, timedOut) }
+
+	cancel := to.AfterFuncFinalize(1, record)
+	clock.Advance(time.Second)
+	cancel()
+	if len(results) != 1 || !results[0] {
+		t.Fatalf("results = %v after firing and cancelling, want [true]", results)
+	}
+
+	results = nil
+	cancel = to.AfterFuncFinalize(1, record)
+	cancel()
+	cancel()
+	clock.Advance(time.Second)
+	if len(results) != 1 || results[0] {
+		t.Fatalf("results = %v after cancelling twice and firing, want [false]", results)
+	}
+}
+
+func TestAfterFuncFinalizeRace(t *testing.T) {
+	to, clock := newFakeTimeout()
+	const n = 1000
+	var timedOut, cancelled atomic.Int64
+	cancels := make([]func(), n)
+	for i := range cancels {
+		cancels[i] = to.AfterFuncFinalize(1, func(fired bool) {
+			if fired {
+				timedOut.Add(1)
+			} else {
+				cancelled.Add(1)
+			}
+		})
+	}
+	//cancels race the entry firing them, each callback still runs exactly once
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for _, cancel := range cancels {
+			cancel()
+		}
+	}()
+	clock.Advance(time.Second)
+	wg.Wait()
+	if got := timedOut.Load() + cancelled.Load(); got != n {
+		t.Fatalf("%d timed out and %d cancelled, want %d in total", timedOut.Load(), cancelled.Load(), n)
+	}
+}
@@ -0,0 +1,127 @@
+package gotimeout
+
+import (
+	"sync"
+	"time"
+)
+
+// fineWheel coalesces short timeouts by the tick they are due in, rather than by their length
+// it is a ring buffer with one slot per tick up to the horizon, each slot holds the entry firing at that tick
+// a single ticker advances the ring, it only runs while there are entries waiting
+type fineWheel struct {
+	mu      sync.Mutex
+	tick    time.Duration
+	horizon time.Duration
+	start   time.Time
+	current uint64
+	entries []*timeoutEntry
+	timers  []*wheelTimer
+	count   int //occupied slots, the ticker stops when it drops to 0
+	done    chan struct{}
+}
+
+//newFineWheel takes tick and horizon as given, Validate rejects a tick that is not positive or a horizon shorter than it
+//such a wheel has no ring, it is never used
+func newFineWheel(tick, horizon time.Duration) *fineWheel {
+	if tick <= 0 || horizon < tick {
+		return &fineWheel{tick: tick, horizon: horizon}
+	}
+	slots := int((horizon+tick-1)/tick) + 1
+	return &fineWheel{
+		tick:    tick,
+		horizon: horizon,
+		entries: make([]*timeoutEntry, slots),
+		timers:  make([]*wheelTimer, slots),
+	}
+}
+
+//entryFor returns the entry firing in the tick d is due in, and whether it was created for it
+func (w *fineWheel) entryFor(t *Timeout, d time.Duration) (*timeoutEntry, bool) {
+	ticks := uint64((d + w.tick - 1) / w.tick)
+	if ticks == 0 {
+		ticks = 1
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done == nil {
+		//first entry since the ring went idle, start the ticker
+		w.start = time.Now()
+		w.current = 0
+		w.done = make(chan struct{})
+		go w.run(w.done)
+	}
+	deadline := w.current + ticks
+	i := deadline % uint64(len(w.timers))
+	if wt := w.timers[i]; wt != nil && wt.deadline == deadline && wt.state.Load() == wheelTimerPending {
+		return w.entries[i], false
+	}
+	if w.timers[i] == nil {
+		w.count++
+	}
+
+	t.stats.cacheMisses.Add(1)
+	entry := t.newEntry(d)
+	wt := &wheelTimer{deadline: deadline, f: noop}
+	w.entries[i] = entry
+	w.timers[i] = wt
+	//the ring fires the entry, there is no timer of its own
+	t.armWith(entry, time.Duration(ticks)*w.tick, func(_ time.Duration, f func()) Timer {
+		wt.f = f
+		return wt
+	})
+	return entry, true
+}
+
+func (w *fineWheel) run(done chan struct{}) {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			//catch up on ticks missed while the goroutine was not scheduled
+			target := uint64(time.Since(w.start) / w.tick)
+			var due []*wheelTimer
+			for w.current < target {
+				w.current++
+				i := w.current % uint64(len(w.timers))
+				if wt := w.timers[i]; wt != nil {
+					due = append(due, wt)
+					w.entries[i] = nil
+					w.timers[i] = nil
+					w.count--
+				}
+			}
+			idle := w.count == 0
+			if idle {
+				w.done = nil
+			}
+			w.mu.Unlock()
+
+			for _, wt := range due {
+				if wt.state.CompareAndSwap(wheelTimerPending, wheelTimerFired) {
+					go wt.f()
+				}
+			}
+			if idle {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+//stop shuts down the ticker, entries still in the ring never fire
+func (w *fineWheel) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done != nil {
+		close(w.done)
+	}
+	w.done = nil
+	w.count = 0
+	clear(w.entries)
+	clear(w.timers)
+}
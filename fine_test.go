@@ -0,0 +1,62 @@
+package gotimeout_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestFineWheelHonoursTimerFunc(t *testing.T) {
+	var mu sync.Mutex
+	var armed []func()
+	timerFunc := func(_ time.Duration, f func()) func() {
+		mu.Lock()
+		defer mu.Unlock()
+		armed = append(armed, f)
+		return func() {}
+	}
+	to := gotimeout.MustNewTimeout(gotimeout.WithFineWheel(time.Millisecond, 100*time.Millisecond), gotimeout.WithTimerFunc(timerFunc))
+	defer to.Stop()
+	fired := make(chan struct{})
+	to.AfterDuration(5*time.Millisecond, func() { close(fired) })
+
+	//nothing but the TimerFunc may fire it
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case <-fired:
+		t.Fatal("a timeout within the fine horizon fired without the TimerFunc")
+	default:
+	}
+	mu.Lock()
+	timers := armed
+	armed = nil
+	mu.Unlock()
+	if len(timers) != 1 {
+		t.Fatalf("%d timers armed through the TimerFunc, want 1", len(timers))
+	}
+	timers[0]()
+	waitFor(t, fired, "timeout fired by the TimerFunc")
+}
+
+func TestFineWheelMaxCallbacksPerBucket(t *testing.T) {
+	to := gotimeout.MustNewTimeout(gotimeout.WithFineWheel(time.Millisecond, 100*time.Millisecond), gotimeout.WithMaxCallbacksPerBucket(2))
+	defer to.Stop()
+	var wg sync.WaitGroup
+	wg.Add(5)
+	for i := 0; i < 5; i++ {
+		to.AfterDuration(50*time.Millisecond, wg.Done)
+	}
+	//the tick's entry stays full, so every callback beyond it gets a unique timer
+	//3 of them unless the ring ticked in between, which moves the later callbacks to the next tick's entry
+	if s := to.Stats(); s.Overflows == 0 || s.UniqueTimers != s.Overflows {
+		t.Fatalf("Stats %+v, want the callbacks beyond 2 spilled to unique timers", s)
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	waitFor(t, done, "callbacks of the fine wheel")
+}
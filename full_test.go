@@ -0,0 +1,44 @@
+package gotimeout
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFullEntryForgetsRejectedSlot(t *testing.T) {
+	to := MustNewTimeout(WithMaxCallbacksPerBucket(1))
+	defer to.Stop()
+	te := to.arm(to.newEntry(time.Hour))
+	first := &callbackSlot{callback: func() {}}
+	if _, result := te.join(first); result != joinAdded {
+		t.Fatalf("expected the first callback to join, got %v", result)
+	}
+	ran := map[string]bool{}
+	keyed := func() { ran["keyed"] = true }
+	deduped := func() { ran["deduped"] = true }
+	newKeyed := func() *callbackSlot { return &callbackSlot{callback: keyed, key: "k"} }
+	newDeduped := func() *callbackSlot {
+		return &callbackSlot{callback: deduped, code: reflect.ValueOf(deduped).Pointer()}
+	}
+	for _, slot := range []*callbackSlot{newKeyed(), newDeduped()} {
+		if _, result := te.join(slot); result != joinFull {
+			t.Fatalf("expected a full entry to reject the callback, got %v", result)
+		}
+	}
+
+	//room again, the retries must join as callbacks of their own rather than match the rejected ones
+	te.removeSlot(first)
+	if joined, result := te.join(newKeyed()); result != joinAdded || joined.index != 1 {
+		t.Fatalf("expected the keyed retry to join as a new callback, got %v at %d", result, joined.index)
+	}
+	to.MaxCallbacksPerBucket = 0
+	if joined, result := te.join(newDeduped()); result != joinAdded || joined.index != 2 {
+		t.Fatalf("expected the deduplicated retry to join as a new callback, got %v at %d", result, joined.index)
+	}
+	to.fire(te)
+	<-to.Idle()
+	if !ran["keyed"] || !ran["deduped"] {
+		t.Fatalf("expected both retries to run, ran %v", ran)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/asynkron/gotimeout
+
+go 1.21
@@ -0,0 +1,40 @@
+package gotimeout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCallbacksNeverRunOnCaller(t *testing.T) {
+	to := MustNewTimeout()
+	caller := goroutineID()
+	ran := make(chan uint64, 3)
+	report := func() { ran <- goroutineID() }
+
+	to.AfterFunc(0, report)
+	to.AfterFunc(3600, report)
+	to.mu.Lock()
+	var held *timeoutEntry
+	for entry := range to.armed {
+		held = entry
+	}
+	to.mu.Unlock()
+	//the entry comes due while paused and fires on Resume
+	to.Pause()
+	to.fire(held)
+	to.Resume()
+	//and one more is drained by DrainAndStop
+	to.AfterFunc(3600, report)
+	to.DrainAndStop()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case id := <-ran:
+			if id == caller {
+				t.Fatal("a callback ran on the goroutine that scheduled it")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("only %d of 3 callbacks ran", i)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Package gotimeoutotel traces the entries of a gotimeout.Timeout as OpenTelemetry spans
+// it lives in its own package so gotimeout itself stays free of dependencies
+package gotimeoutotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracer implements gotimeout.Tracer, it starts a span around the callbacks of every entry that fires
+// spans are named after the timeout length, e.g. "gotimeout.fire 10s"
+type Tracer struct {
+	ctx    context.Context
+	tracer trace.Tracer
+}
+
+var _ gotimeout.Tracer = (*Tracer)(nil)
+
+// NewTracer creates a Tracer starting its spans with tracer, as children of the span in ctx if there is one
+// pass context.Background() for every fired entry to start a trace of its own
+func NewTracer(ctx context.Context, tracer trace.Tracer) *Tracer {
+	return &Tracer{
+		ctx:    ctx,
+		tracer: tracer,
+	}
+}
+
+// Fire implements gotimeout.Tracer
+func (t *Tracer) Fire(timeout time.Duration, callbacks int) func() {
+	_, span := t.tracer.Start(t.ctx, "gotimeout.fire "+timeout.String(),
+		trace.WithAttributes(
+			attribute.Int64("gotimeout.timeout_ms", timeout.Milliseconds()),
+			attribute.Int("gotimeout.callbacks", callbacks),
+		),
+	)
+	return func() { span.End() }
+}
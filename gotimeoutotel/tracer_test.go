@@ -0,0 +1,51 @@
+package gotimeoutotel_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+	"github.com/asynkron/gotimeout/gotimeoutotel"
+	"github.com/asynkron/gotimeout/gotimeouttest"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("test")
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	clock := gotimeouttest.NewFakeClock(time.Unix(0, 0))
+	to := gotimeout.MustNewTimeout(gotimeout.WithClock(clock), gotimeout.WithTracer(gotimeoutotel.NewTracer(ctx, tracer)))
+	done := make(chan struct{}, 2)
+	to.AfterFunc(2, func() { done <- struct{}{} })
+	to.AfterFunc(2, func() { done <- struct{}{} })
+	clock.Advance(2 * time.Second)
+	<-done
+	<-done
+	to.Stop()
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expected the fire span and the parent, got %d spans", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "gotimeout.fire 2s" {
+		t.Fatalf("unexpected span name %q", span.Name())
+	}
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	if attrs["gotimeout.timeout_ms"].AsInt64() != 2000 || attrs["gotimeout.callbacks"].AsInt64() != 2 {
+		t.Fatalf("unexpected attributes %v", span.Attributes())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() || span.SpanContext().TraceID() != parent.SpanContext().TraceID() {
+		t.Fatal("the fire span is not a child of the span in ctx")
+	}
+}
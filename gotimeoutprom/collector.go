@@ -0,0 +1,59 @@
+// Package gotimeoutprom exports the counters of a gotimeout.Timeout as Prometheus metrics
+// it lives in its own package so gotimeout itself stays free of dependencies
+package gotimeoutprom
+
+import (
+	"github.com/asynkron/gotimeout"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector implements prometheus.Collector for a Timeout
+// metrics are read from Timeout.Stats on every scrape, which never blocks scheduling
+type Collector struct {
+	timeout *gotimeout.Timeout
+
+	activeEntries *prometheus.Desc
+	cacheHits     *prometheus.Desc
+	cacheMisses   *prometheus.Desc
+	uniqueTimers  *prometheus.Desc
+	fired         *prometheus.Desc
+	timersSaved   *prometheus.Desc
+}
+
+// NewCollector creates a Collector for the Timeout, constLabels are added to every metric
+// e.g. to tell several instances apart
+func NewCollector(timeout *gotimeout.Timeout, constLabels prometheus.Labels) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName("gotimeout", "", name), help, nil, constLabels)
+	}
+	return &Collector{
+		timeout:       timeout,
+		activeEntries: desc("active_entries", "Entries with a pending timer."),
+		cacheHits:     desc("cache_hits_total", "Callbacks that joined an existing entry."),
+		cacheMisses:   desc("cache_misses_total", "Entries created for a cached timeout length."),
+		uniqueTimers:  desc("unique_timers_total", "Unique timers created for timeout lengths that are not cached."),
+		fired:         desc("callbacks_fired_total", "Callbacks that ran."),
+		timersSaved:   desc("timers_saved_total", "Callbacks that joined an entry with a running timer instead of arming their own."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.activeEntries
+	ch <- c.cacheHits
+	ch <- c.cacheMisses
+	ch <- c.uniqueTimers
+	ch <- c.fired
+	ch <- c.timersSaved
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.timeout.Stats()
+	ch <- prometheus.MustNewConstMetric(c.activeEntries, prometheus.GaugeValue, float64(stats.ActiveEntries))
+	ch <- prometheus.MustNewConstMetric(c.cacheHits, prometheus.CounterValue, float64(stats.CacheHits))
+	ch <- prometheus.MustNewConstMetric(c.cacheMisses, prometheus.CounterValue, float64(stats.CacheMisses))
+	ch <- prometheus.MustNewConstMetric(c.uniqueTimers, prometheus.CounterValue, float64(stats.UniqueTimers))
+	ch <- prometheus.MustNewConstMetric(c.fired, prometheus.CounterValue, float64(stats.Fired))
+	ch <- prometheus.MustNewConstMetric(c.timersSaved, prometheus.CounterValue, float64(stats.TimersSaved))
+}
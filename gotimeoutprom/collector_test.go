@@ -0,0 +1,58 @@
+package gotimeoutprom_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+	"github.com/asynkron/gotimeout/gotimeoutprom"
+	"github.com/asynkron/gotimeout/gotimeouttest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	clock := gotimeouttest.NewFakeClock(time.Unix(0, 0))
+	to := gotimeout.MustNewTimeout(gotimeout.WithClock(clock), gotimeout.WithMaxSeconds(10), gotimeout.WithFallback(gotimeout.FallbackUnique))
+	for i := 0; i < 3; i++ {
+		to.AfterFunc(1, func() {})
+	}
+	to.AfterFunc(2, func() {})
+	to.AfterFunc(20, func() {})
+	clock.Advance(time.Second)
+
+	collector := gotimeoutprom.NewCollector(to, prometheus.Labels{"instance": "test"})
+	stats := to.Stats()
+	if stats.Fired != 3 || stats.CacheHits != 2 || stats.ActiveEntries != 2 {
+		t.Fatalf("unexpected Stats %+v", stats)
+	}
+	expected := fmt.Sprintf(`
+# HELP gotimeout_active_entries Entries with a pending timer.
+# TYPE gotimeout_active_entries gauge
+gotimeout_active_entries{instance="test"} %d
+# HELP gotimeout_cache_hits_total Callbacks that joined an existing entry.
+# TYPE gotimeout_cache_hits_total counter
+gotimeout_cache_hits_total{instance="test"} %d
+# HELP gotimeout_cache_misses_total Entries created for a cached timeout length.
+# TYPE gotimeout_cache_misses_total counter
+gotimeout_cache_misses_total{instance="test"} %d
+# HELP gotimeout_callbacks_fired_total Callbacks that ran.
+# TYPE gotimeout_callbacks_fired_total counter
+gotimeout_callbacks_fired_total{instance="test"} %d
+# HELP gotimeout_timers_saved_total Callbacks that joined an entry with a running timer instead of arming their own.
+# TYPE gotimeout_timers_saved_total counter
+gotimeout_timers_saved_total{instance="test"} %d
+# HELP gotimeout_unique_timers_total Unique timers created for timeout lengths that are not cached.
+# TYPE gotimeout_unique_timers_total counter
+gotimeout_unique_timers_total{instance="test"} %d
+`, stats.ActiveEntries, stats.CacheHits, stats.CacheMisses, stats.Fired, stats.TimersSaved, stats.UniqueTimers)
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+	problems, err := testutil.CollectAndLint(collector)
+	if err != nil || len(problems) > 0 {
+		t.Fatalf("lint: %v %v", err, problems)
+	}
+}
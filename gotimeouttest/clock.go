@@ -0,0 +1,109 @@
+// Package gotimeouttest provides a fake gotimeout.Clock, to test code using a gotimeout.Timeout without sleeping
+package gotimeouttest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+// FakeClock is a gotimeout.Clock that only moves when told to
+// its timers fire from within Advance and Set, on the calling goroutine and in deadline order
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+	seq    uint64 //keeps timers with the same deadline in the order they were armed
+}
+
+var _ gotimeout.Clock = (*FakeClock)(nil)
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	seq      uint64
+	f        func()
+}
+
+// NewFakeClock creates a FakeClock set to now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now implements gotimeout.Clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc implements gotimeout.Clock, f runs once Advance or Set moves the clock past d from now
+// unlike time.AfterFunc a timer that is due right away waits for the next Advance as well, Advance(0) fires it
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) gotimeout.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seq++
+	ft := &fakeTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		seq:      c.seq,
+		f:        f,
+	}
+	c.timers = append(c.timers, ft)
+	return ft
+}
+
+// Stop implements gotimeout.Timer
+func (ft *fakeTimer) Stop() bool {
+	c := ft.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, armed := range c.timers {
+		if armed == ft {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Advance moves the clock forward by d, firing every timer that comes due on the way
+// while a timer fires, Now returns its deadline, so timers it arms are due relative to it
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+	c.Set(target)
+}
+
+// Set moves the clock to now, firing every timer whose deadline is not after it
+// setting the clock back fires nothing, timers keep their deadlines
+func (c *FakeClock) Set(now time.Time) {
+	for {
+		c.mu.Lock()
+		next := -1
+		for i, ft := range c.timers {
+			if ft.deadline.After(now) {
+				continue
+			}
+			if next < 0 || ft.deadline.Before(c.timers[next].deadline) ||
+				(ft.deadline.Equal(c.timers[next].deadline) && ft.seq < c.timers[next].seq) {
+				next = i
+			}
+		}
+		if next < 0 {
+			c.now = now
+			c.mu.Unlock()
+			return
+		}
+		ft := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		if ft.deadline.After(c.now) {
+			c.now = ft.deadline
+		}
+		c.mu.Unlock()
+		//outside the lock, f may arm or stop timers itself
+		ft.f()
+	}
+}
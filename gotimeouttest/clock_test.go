@@ -0,0 +1,78 @@
+package gotimeouttest_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+	"github.com/asynkron/gotimeout/gotimeouttest"
+)
+
+func TestFakeClockAdvance(t *testing.T) {
+	clock := gotimeouttest.NewFakeClock(time.Unix(0, 0))
+	to := gotimeout.MustNewTimeout(gotimeout.WithClock(clock))
+	var fired atomic.Int64
+	to.AfterFunc(5, func() { fired.Add(1) })
+	to.AfterFunc(10, func() { fired.Add(10) })
+
+	//callbacks run inside Advance, nothing to wait for
+	clock.Advance(4 * time.Second)
+	if got := fired.Load(); got != 0 {
+		t.Fatalf("fired %d before the deadline", got)
+	}
+	clock.Advance(time.Second)
+	if got := fired.Load(); got != 1 {
+		t.Fatalf("fired %d after 5s, want 1", got)
+	}
+	clock.Advance(10 * time.Second)
+	if got := fired.Load(); got != 11 {
+		t.Fatalf("fired %d after 15s, want 11", got)
+	}
+}
+
+func TestFakeClockSet(t *testing.T) {
+	start := time.Unix(0, 0)
+	clock := gotimeouttest.NewFakeClock(start)
+	to := gotimeout.MustNewTimeout(gotimeout.WithClock(clock))
+	var fired atomic.Bool
+	to.AfterFunc(3, func() { fired.Store(true) })
+
+	clock.Set(start.Add(-time.Hour))
+	if fired.Load() {
+		t.Fatal("setting the clock back fired the callback")
+	}
+	clock.Set(start.Add(3 * time.Second))
+	if !fired.Load() {
+		t.Fatal("callback did not fire once the clock reached its deadline")
+	}
+	if got := clock.Now(); !got.Equal(start.Add(3 * time.Second)) {
+		t.Fatalf("Now() = %v after Set", got)
+	}
+}
+
+func TestFakeClockOrder(t *testing.T) {
+	clock := gotimeouttest.NewFakeClock(time.Unix(0, 0))
+	var order []int
+	clock.AfterFunc(2*time.Second, func() { order = append(order, 2) })
+	clock.AfterFunc(time.Second, func() { order = append(order, 1) })
+	stopped := clock.AfterFunc(time.Second, func() { order = append(order, -1) })
+	clock.AfterFunc(time.Second, func() {
+		order = append(order, 3)
+		//armed from a callback, due relative to the deadline being fired
+		clock.AfterFunc(500*time.Millisecond, func() { order = append(order, 4) })
+	})
+	if !stopped.Stop() {
+		t.Fatal("Stop on an armed timer returned false")
+	}
+	clock.Advance(2 * time.Second)
+	want := []int{1, 3, 4, 2}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
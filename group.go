@@ -0,0 +1,84 @@
+package gotimeout
+
+import "sync"
+
+// Group collects callbacks that fire together or not at all, see AfterFuncGroup
+type Group struct {
+	t       *Timeout
+	seconds int
+
+	mu        sync.Mutex
+	callbacks []TimeoutCallback
+	cancel    CancelFunc
+	committed bool
+	aborted   bool
+	fired     bool
+}
+
+func AfterFuncGroup(seconds int) *Group {
+	return defaultTimeout().AfterFuncGroup(seconds)
+}
+
+// AfterFuncGroup returns an empty Group, its callbacks are only scheduled once Commit is called
+// a Group that is never committed schedules nothing, its callbacks are collected with it
+func (t *Timeout) AfterFuncGroup(seconds int) *Group {
+	return &Group{
+		t:       t,
+		seconds: seconds,
+	}
+}
+
+// Add adds a callback to the Group, callbacks run in the order they were added
+// callbacks added after Commit or Abort are ignored, they are not part of the unit
+func (g *Group) Add(callback TimeoutCallback) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.committed || g.aborted {
+		return
+	}
+	g.callbacks = append(g.callbacks, callback)
+}
+
+// Commit schedules the callbacks as a single unit, they go into one entry and run one after the other when it fires
+// a panicking callback does not keep the rest of the Group from running unless PanicPolicy is PanicStop, committing a second time is a no-op
+func (g *Group) Commit() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.committed || g.aborted {
+		return
+	}
+	g.committed = true
+	callbacks := g.callbacks
+	g.callbacks = nil
+	g.cancel = g.t.schedule(secondsToDuration(g.seconds), func() {
+		g.mu.Lock()
+		if g.aborted {
+			//the entry took the Group right before Abort cancelled it
+			g.mu.Unlock()
+			return
+		}
+		g.fired = true
+		g.mu.Unlock()
+		for _, callback := range callbacks {
+			if g.t.stopsOn(g.t.invoke(callback)) {
+				break
+			}
+		}
+	})
+}
+
+// Abort cancels the Group, it returns false if the callbacks already fired or the Group was aborted before
+// aborting a Group that was not committed yet just discards its callbacks
+func (g *Group) Abort() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.aborted || g.fired {
+		return false
+	}
+	g.aborted = true
+	g.callbacks = nil
+	if g.cancel != nil {
+		g.cancel()
+	}
+	return true
+}
@@ -0,0 +1,82 @@
+package gotimeout
+
+import "sync"
+
+// Handle controls a single callback scheduled with AfterFuncHandle, similar to a *time.Timer
+// it covers both ways a callback can be scheduled, see Result for which one it got:
+// a callback sharing an entry does not own a timer, Stop removes it from the entry and Reset moves it to the entry of the new length,
+// the timer of the entry keeps running for the other callbacks and the callback fires with the entry, within CacheWindow
+// a callback with a unique timer, e.g. beyond MaxSeconds with FallbackUnique or shorter than half a bucket, owns that timer,
+// Stop stops it right away and Reset replaces it, the callback fires at its exact timeout like with time.AfterFunc
+type Handle struct {
+	t        *Timeout
+	callback TimeoutCallback
+
+	mu     sync.Mutex
+	gen    int //bumped on every Stop/Reset, a callback only runs if its generation is still current
+	cancel CancelFunc
+	active bool
+	result ScheduleResult
+}
+
+func AfterFuncHandle(seconds int, callback TimeoutCallback) *Handle {
+	return defaultTimeout().AfterFuncHandle(seconds, callback)
+}
+
+// AfterFuncHandle works like AfterFunc, but returns a Handle that can stop or reset the callback
+func (t *Timeout) AfterFuncHandle(seconds int, callback TimeoutCallback) *Handle {
+	h := &Handle{
+		t:        t,
+		callback: callback,
+	}
+	h.Reset(seconds)
+	return h
+}
+
+// Stop prevents the callback from running, it returns false if the callback already ran or was stopped
+func (h *Handle) Stop() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.stopLocked()
+}
+
+// Reset reschedules the callback to run seconds from now, it returns whether the callback was still pending
+// like time.Timer.Reset, calling Reset after the callback ran schedules it to run once more
+func (h *Handle) Reset(seconds int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	wasActive := h.stopLocked()
+
+	gen := h.gen
+	h.active = true
+	h.cancel, h.result = h.t.scheduleResult(secondsToDuration(seconds), func() {
+		h.mu.Lock()
+		if h.gen != gen {
+			//stopped or reset after the entry took the callback
+			h.mu.Unlock()
+			return
+		}
+		h.active = false
+		h.mu.Unlock()
+		h.callback()
+	})
+	return wasActive
+}
+
+// Result tells how the callback was scheduled by AfterFuncHandle or the last Reset, ScheduleUnique if it owns a timer
+func (h *Handle) Result() ScheduleResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.result
+}
+
+func (h *Handle) stopLocked() bool {
+	wasActive := h.active
+	h.gen++
+	h.active = false
+	if h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+	}
+	return wasActive
+}
@@ -0,0 +1,38 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestHandleUniqueFallback(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(5), gotimeout.WithFallback(gotimeout.FallbackUnique))
+	fired := 0
+	h := to.AfterFuncHandle(10, func() { fired++ })
+	if h.Result() != gotimeout.ScheduleUnique {
+		t.Fatalf("expected a unique timer beyond MaxSeconds, got %v", h.Result())
+	}
+	if !h.Stop() || to.Stats().ActiveEntries != 0 {
+		t.Fatal("expected Stop to stop the unique timer")
+	}
+	//reset to a cached length, the handle now shares an entry
+	if h.Reset(2) {
+		t.Fatal("expected Reset of a stopped handle to report it was not pending")
+	}
+	if h.Result() != gotimeout.ScheduleCreated {
+		t.Fatalf("expected the callback to create its entry, got %v", h.Result())
+	}
+	if !h.Reset(20) || h.Result() != gotimeout.ScheduleUnique {
+		t.Fatal("expected Reset to move the callback back to a unique timer")
+	}
+	clock.Advance(15 * time.Second)
+	if fired != 0 {
+		t.Fatal("the callback fired before its reset timeout")
+	}
+	clock.Advance(5 * time.Second)
+	if fired != 1 {
+		t.Fatalf("expected the callback to fire once, fired %d times", fired)
+	}
+}
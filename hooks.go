@@ -0,0 +1,45 @@
+package gotimeout
+
+import "time"
+
+// TestingHooks are called at the points the guarantees of a Timeout hinge on, so tests can verify them rather than assume them
+// e.g. that a callback runs exactly once, that the callbacks of an entry run in ExecutionOrder, or that a cancel that won kept its callback from running
+// every hook is optional and runs synchronously, outside of the entry lock, on the goroutine of the event, so it should be quick
+// the behavior of the Timeout is the same with and without hooks, they are meant for tests and cost a check per event otherwise
+type TestingHooks struct {
+	// Added is called when callbacks joined an entry, n at once for AfterFuncBatch, it is not called for callbacks that run right away
+	Added func(timeout time.Duration, n int)
+
+	// Triggered is called when an entry fired and took its callbacks, before any of them runs
+	Triggered func(timeout time.Duration)
+
+	// Ran is called right before a callback runs, timeout is zero for callbacks that ran without an entry, e.g. AfterFunc(0)
+	Ran func(timeout time.Duration)
+
+	// CancelWon is called when a cancel removed a callback before its entry fired, the callback will not run
+	CancelWon func(timeout time.Duration)
+}
+
+func (h *TestingHooks) added(timeout time.Duration, n int) {
+	if h != nil && h.Added != nil {
+		h.Added(timeout, n)
+	}
+}
+
+func (h *TestingHooks) triggered(timeout time.Duration) {
+	if h != nil && h.Triggered != nil {
+		h.Triggered(timeout)
+	}
+}
+
+func (h *TestingHooks) ran(timeout time.Duration) {
+	if h != nil && h.Ran != nil {
+		h.Ran(timeout)
+	}
+}
+
+func (h *TestingHooks) cancelWon(timeout time.Duration) {
+	if h != nil && h.CancelWon != nil {
+		h.CancelWon(timeout)
+	}
+}
@@ -0,0 +1,30 @@
+package gotimeout_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestTestingHooks(t *testing.T) {
+	var events []string
+	hooks := &gotimeout.TestingHooks{
+		Added:     func(_ time.Duration, n int) { events = append(events, "added") },
+		Triggered: func(time.Duration) { events = append(events, "triggered") },
+		Ran:       func(time.Duration) { events = append(events, "ran") },
+		CancelWon: func(time.Duration) { events = append(events, "cancelled") },
+	}
+	to, clock := newFakeTimeout(gotimeout.WithTestingHooks(hooks))
+	to.AfterFunc(1, func() { events = append(events, "first") })
+	cancel := to.AfterFuncCancellable(1, func() { events = append(events, "second") })
+	cancel()
+	//a second cancel loses, the callback was removed already
+	cancel()
+	clock.Advance(2 * time.Second)
+	want := []string{"added", "added", "cancelled", "triggered", "ran", "first"}
+	if !slices.Equal(events, want) {
+		t.Fatalf("got events %v, want %v", events, want)
+	}
+}
@@ -0,0 +1,53 @@
+package gotimeout
+
+func AfterFuncID(seconds int, callback TimeoutCallback) uint64 {
+	return defaultTimeout().AfterFuncID(seconds, callback)
+}
+
+func Status(id uint64) (fired bool, exists bool) {
+	return defaultTimeout().Status(id)
+}
+
+// AfterFuncID works like AfterFunc, but returns an id to query with Status whether the callback fired yet
+// ids count up from 1 and are never reused, 0 means the callback could not be scheduled
+// only ids of callbacks that are still waiting are tracked, so polling ids of fired or removed callbacks costs no memory
+func (t *Timeout) AfterFuncID(seconds int, callback TimeoutCallback) uint64 {
+	t.idsMu.Lock()
+	t.lastID++
+	id := t.lastID
+	if t.ids == nil {
+		t.ids = make(map[uint64]struct{})
+	}
+	t.ids[id] = struct{}{}
+	t.idsMu.Unlock()
+
+	_, err := t.scheduleSlot(secondsToDuration(seconds), &callbackSlot{id: id, callback: func() {
+		t.idsMu.Lock()
+		delete(t.ids, id)
+		t.idsMu.Unlock()
+		callback()
+	}})
+	if err != nil {
+		t.idsMu.Lock()
+		delete(t.ids, id)
+		t.idsMu.Unlock()
+		t.report(err)
+		return 0
+	}
+	return id
+}
+
+// Status tells if the callback of id fired, fired is true once it started running
+// it is true as well once the callback was removed without running, by CancelAll, Extract, Stop or a PanicStop, the id is then forgotten
+// the same way, so false always means the callback is still waiting, exists is false for ids never handed out or handed out before Reset
+func (t *Timeout) Status(id uint64) (fired bool, exists bool) {
+	t.idsMu.Lock()
+	defer t.idsMu.Unlock()
+	if _, ok := t.ids[id]; ok {
+		return false, true
+	}
+	if id == 0 || id <= t.resetID || id > t.lastID {
+		return false, false
+	}
+	return true, true
+}
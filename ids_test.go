@@ -0,0 +1,53 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAfterFuncIDStatus(t *testing.T) {
+	to, clock := newFakeTimeout()
+	first := to.AfterFuncID(1, func() {})
+	second := to.AfterFuncID(2, func() {})
+	if first != 1 || second != 2 {
+		t.Fatalf("expected ids to count up from 1, got %d and %d", first, second)
+	}
+	if fired, exists := to.Status(first); fired || !exists {
+		t.Fatalf("Status(%d) = %v, %v before firing, want false, true", first, fired, exists)
+	}
+	clock.Advance(time.Second)
+	if fired, exists := to.Status(first); !fired || !exists {
+		t.Fatalf("Status(%d) = %v, %v after firing, want true, true", first, fired, exists)
+	}
+	if fired, _ := to.Status(second); fired {
+		t.Fatal("a callback that is not due yet reports it fired")
+	}
+	for _, id := range []uint64{0, 3} {
+		if _, exists := to.Status(id); exists {
+			t.Fatalf("Status(%d) reports an id that was never handed out", id)
+		}
+	}
+	to.Reset()
+	if _, exists := to.Status(second); exists {
+		t.Fatal("an id handed out before Reset still exists")
+	}
+}
+
+func TestAfterFuncIDRemoved(t *testing.T) {
+	to, _ := newFakeTimeout()
+	cancelled := to.AfterFuncID(1, func() {})
+	to.CancelAll(1)
+	extracted := to.AfterFuncID(2, func() {})
+	to.Extract()
+	stopped := to.AfterFuncID(3, func() {})
+	to.Stop()
+	//removed callbacks are forgotten rather than reported waiting forever
+	for _, id := range []uint64{cancelled, extracted, stopped} {
+		if fired, exists := to.Status(id); !fired || !exists {
+			t.Fatalf("Status(%d) = %v, %v for a removed callback, want true, true", id, fired, exists)
+		}
+	}
+	if id := to.AfterFuncID(1, func() {}); id != 0 {
+		t.Fatalf("expected id 0 after Stop, got %d", id)
+	}
+}
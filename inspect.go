@@ -0,0 +1,160 @@
+package gotimeout
+
+import (
+	"math"
+	"time"
+)
+
+//size returns the number of callbacks that have not fired yet
+func (te *timeoutEntry) size() int {
+	te.Lock()
+	defer te.Unlock()
+	if te.completed {
+		return 0
+	}
+	te.gather()
+	return te.live
+}
+
+// Pending returns the number of callbacks waiting in the current entry for the timeout length
+// it is 0 if there is no entry, or if the entry already fired
+// callbacks of older entries for the same length that are still waiting are only counted by PendingTotal
+func (t *Timeout) Pending(seconds int) int {
+	entries := t.getEntries()
+	bucket, cached := t.bucketIn(entries, secondsToDuration(seconds))
+	if !cached {
+		return 0
+	}
+	entry := entries[bucket].Load()
+	if entry == nil {
+		return 0
+	}
+	return entry.size()
+}
+
+// PendingTotal returns the number of callbacks waiting in any entry, unique ones included
+func (t *Timeout) PendingTotal() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	total := 0
+	for entry := range t.armed {
+		total += entry.size()
+	}
+	return total
+}
+
+// NextFire returns when the current entry for the timeout length fires, and false if there is no entry waiting to fire
+// the entry may be past the cache window already, then new callbacks for the length go into a new entry
+func (t *Timeout) NextFire(seconds int) (time.Time, bool) {
+	entries := t.getEntries()
+	bucket, cached := t.bucketIn(entries, secondsToDuration(seconds))
+	if !cached {
+		return time.Time{}, false
+	}
+	entry := entries[bucket].Load()
+	if entry == nil {
+		return time.Time{}, false
+	}
+	entry.Lock()
+	defer entry.Unlock()
+	if entry.completed {
+		return time.Time{}, false
+	}
+	return entry.deadline, true
+}
+
+// BucketInfo describes a cached entry at the time Snapshot was taken
+// it marshals to JSON as is, e.g. for a debug endpoint, times as RFC 3339 and Timeout in nanoseconds like any time.Duration
+type BucketInfo struct {
+	Timeout time.Duration `json:"timeout"` //timeout length of the bucket
+	Created time.Time     `json:"created"` //when the entry was created, it is shared until the cache window elapses
+	Fires   time.Time     `json:"fires"`   //when the entry is due to fire, jitter included
+	Pending int           `json:"pending"` //callbacks waiting in the entry
+}
+
+// Snapshot returns the cached entries that are still shared by new callbacks, ordered by timeout length
+// entries that fired or are past the cache window are left out, the result is a copy that does not change afterwards
+func (t *Timeout) Snapshot() []BucketInfo {
+	now := t.now()
+	window := t.cacheWindow()
+	var buckets []BucketInfo
+	entries := t.getEntries()
+	for i := range entries {
+		entry := entries[i].Load()
+		if entry == nil || entry.expired(now, window) {
+			continue
+		}
+		entry.Lock()
+		entry.gather()
+		//an entry that is not armed yet has no deadline
+		if !entry.completed && !entry.deadline.IsZero() {
+			buckets = append(buckets, BucketInfo{
+				Timeout: entry.timeout,
+				Created: entry.timestamp,
+				Fires:   entry.deadline,
+				Pending: entry.live,
+			})
+		}
+		entry.Unlock()
+	}
+	return buckets
+}
+
+// EffectiveWindow returns the earliest and latest a callback scheduled with AfterFunc(seconds) fires, relative to scheduling
+// it follows the configuration of the Timeout: bucketing, CacheWindow, Jitter, Fallback and the timing wheels
+// the latest time does not account for a busy runtime or callbacks queued before it, only for what the Timeout adds
+// a timeout rejected by FallbackError, and a zero or negative one, report 0 for both
+func (t *Timeout) EffectiveWindow(seconds int) (earliest, latest time.Duration) {
+	d := secondsToDuration(seconds)
+	if d <= 0 || t.rejects(d) {
+		return 0, 0
+	}
+
+	var late time.Duration
+	if t.wheel != nil && t.TimerFunc == nil {
+		late = t.wheel.tick
+	}
+	if t.fineFor(d) {
+		//coalesced by the tick the callback is due in, not by length
+		return d, d + t.fine.tick
+	}
+
+	length := d
+	bucket, cached := t.bucketFor(d)
+	switch {
+	case cached:
+		length = time.Duration(bucket) * t.granularity()
+	case d < t.granularity() || t.Fallback == FallbackUnique:
+		//a unique timer, neither shared nor jittered
+		return d, d + late
+	case t.Fallback == FallbackClamp:
+		length = time.Duration(len(t.getEntries())-1) * t.granularity()
+	default:
+		length = time.Duration(t.longKey(d)) * time.Second
+	}
+
+	//an entry is joined until it is CacheWindow old, or MaxEntryAge if that comes first
+	window := t.cacheWindow()
+	if t.MaxEntryAge > 0 {
+		window = min(window, t.MaxEntryAge)
+	}
+	earliest = length - window
+	latest = length + late
+	if t.Coalesce > 0 && (cached || t.Fallback == FallbackClamp) {
+		spread := secondsToDuration(t.Coalesce)
+		earliest -= spread
+		latest += spread
+	}
+	if t.Jitter > 0 {
+		earliest -= t.Jitter
+		latest += t.Jitter
+	}
+	if earliest < 0 {
+		earliest = 0
+	}
+	if latest < length {
+		//overflowed past the longest duration
+		latest = math.MaxInt64
+	}
+	return earliest, latest
+}
@@ -0,0 +1,121 @@
+package gotimeout_test
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestInspectWhileScheduling(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(5), gotimeout.WithCacheWindow(20*time.Millisecond))
+	const goroutines, perGoroutine = 8, 300
+	var fired atomic.Int64
+	stop := advancing(clock)
+	//the slots of the entries array are written by schedulers and read by the inspection functions without a lock
+	done := make(chan struct{})
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				to.Pending(1)
+				to.PendingTotal()
+				to.NextFire(2)
+				to.Snapshot()
+			}
+		}
+	}()
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				to.AfterFunc(1+(g+i)%5, func() { fired.Add(1) })
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(done)
+	readers.Wait()
+	stop()
+	clock.Advance(10 * time.Second)
+	to.Wait()
+	if got := fired.Load(); got != goroutines*perGoroutine {
+		t.Fatalf("%d of %d callbacks fired", got, goroutines*perGoroutine)
+	}
+	if got := to.PendingTotal(); got != 0 {
+		t.Fatalf("PendingTotal() = %d once everything fired", got)
+	}
+}
+
+func TestCacheWindowFireDelay(t *testing.T) {
+	const window = 300 * time.Millisecond
+	to, clock := newFakeTimeout(gotimeout.WithCacheWindow(window))
+	start := clock.Now()
+	delays := map[time.Duration]time.Duration{}
+	//schedules at each offset and records how long after scheduling the callback fired
+	for _, offset := range []time.Duration{0, 100 * time.Millisecond, window, window + time.Millisecond, 600 * time.Millisecond} {
+		clock.Set(start.Add(offset))
+		offset := offset
+		to.AfterFunc(1, func() { delays[offset] = clock.Now().Sub(start) - offset })
+	}
+	clock.Advance(2 * time.Second)
+
+	//joining an entry of age a fires a early, up to the window, past it a new entry fires on time
+	want := map[time.Duration]time.Duration{
+		0:                         time.Second,
+		100 * time.Millisecond:    900 * time.Millisecond,
+		window:                    time.Second - window,
+		window + time.Millisecond: time.Second,
+		600 * time.Millisecond:    701 * time.Millisecond, //joins the entry created at 301ms
+	}
+	for offset, delay := range want {
+		if delays[offset] != delay {
+			t.Errorf("scheduled at %v: fired after %v, want %v", offset, delays[offset], delay)
+		}
+	}
+	earliest, latest := to.EffectiveWindow(1)
+	if earliest != time.Second-window || latest != time.Second {
+		t.Fatalf("EffectiveWindow(1) = %v, %v, want %v, %v", earliest, latest, time.Second-window, time.Second)
+	}
+	for offset, delay := range delays {
+		if delay < earliest || delay > latest {
+			t.Errorf("scheduled at %v: delay %v outside of EffectiveWindow", offset, delay)
+		}
+	}
+}
+
+func TestSnapshotJSON(t *testing.T) {
+	to, _ := newFakeTimeout()
+	to.AfterFunc(2, func() {})
+	data, err := json.Marshal(to.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []struct {
+		Timeout int64  `json:"timeout"`
+		Created string `json:"created"`
+		Fires   string `json:"fires"`
+		Pending int    `json:"pending"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded) != 1 || decoded[0].Timeout != int64(2*time.Second) || decoded[0].Pending != 1 {
+		t.Fatalf("unexpected snapshot %s", data)
+	}
+	for _, s := range []string{decoded[0].Created, decoded[0].Fires} {
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			t.Fatalf("time %q is not RFC 3339: %v", s, err)
+		}
+	}
+}
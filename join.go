@@ -0,0 +1,62 @@
+package gotimeout
+
+import "time"
+
+func JoinExisting(seconds int, callback TimeoutCallback) bool {
+	return defaultTimeout().JoinExisting(seconds, callback)
+}
+
+// JoinExisting adds the callback to the entry already waiting for the timeout length, and schedules nothing if there is none
+// unlike AfterFunc it joins the entry however old it is, so the callback fires at that entry's deadline, up to seconds early
+// this keeps a length firing at most once per entry, e.g. for rate limiting aligned to a schedule that is already running
+// it returns false if no entry is waiting, the entry already fired or is full, or the Timeout is stopped
+// with Strict, calling it from within a callback reports ErrReentrant and returns false
+// timeouts the cached entries do not coalesce, like those of the fine wheel or with FallbackUnique, never have an entry to join
+func (t *Timeout) JoinExisting(seconds int, callback TimeoutCallback) bool {
+	if t.stopped.Load() || t.overloaded(1) {
+		return false
+	}
+	if t.reentrant() {
+		t.report(ErrReentrant)
+		return false
+	}
+	entry := t.existingEntry(secondsToDuration(seconds))
+	if entry == nil {
+		return false
+	}
+	if _, result := entry.join(t.userSlot(callback)); result != joinAdded {
+		return false
+	}
+	t.stats.cacheHits.Add(1)
+	t.stats.timersSaved.Add(1)
+	return true
+}
+
+//existingEntry returns the entry AfterFunc would look up for d, without creating one, nil if there is none
+func (t *Timeout) existingEntry(d time.Duration) *timeoutEntry {
+	if d <= 0 || t.fineFor(d) {
+		return nil
+	}
+	entries := t.getEntries()
+	bucket, cached := t.bucketIn(entries, d)
+	if !cached {
+		if d < t.granularity() {
+			return nil
+		}
+		switch t.Fallback {
+		case FallbackClamp:
+			bucket = len(entries) - 1
+		case FallbackCoalesce:
+			t.longMu.Lock()
+			defer t.longMu.Unlock()
+			entry := t.long[t.longKey(d)]
+			if entry != nil {
+				t.longLRU.MoveToFront(entry.longUse)
+			}
+			return entry
+		default:
+			return nil
+		}
+	}
+	return entries[bucket].Load()
+}
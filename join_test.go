@@ -0,0 +1,40 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestJoinExisting(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10))
+	start := clock.Now()
+	var firedAt []time.Duration
+	record := func() { firedAt = append(firedAt, clock.Now().Sub(start)) }
+
+	if to.JoinExisting(5, record) {
+		t.Fatal("JoinExisting scheduled without an entry to join")
+	}
+	to.AfterFunc(5, record)
+	to.AfterFunc(20, record)
+	//long past the cache window the entries are still joined, at their deadline
+	clock.Advance(3 * time.Second)
+	if !to.JoinExisting(5, record) || !to.JoinExisting(20, record) {
+		t.Fatal("JoinExisting did not join the waiting entries")
+	}
+	if to.JoinExisting(6, record) {
+		t.Fatal("JoinExisting joined an entry of another length")
+	}
+	clock.Advance(2 * time.Second)
+	if len(firedAt) != 2 || firedAt[1] != 5*time.Second {
+		t.Fatalf("fired at %v, want both callbacks of 5s at 5s", firedAt)
+	}
+	if to.JoinExisting(5, record) {
+		t.Fatal("JoinExisting joined an entry that already fired")
+	}
+	clock.Advance(time.Minute)
+	if len(firedAt) != 4 || firedAt[3] != 20*time.Second {
+		t.Fatalf("fired at %v, want both callbacks of 20s at 20s", firedAt)
+	}
+}
@@ -0,0 +1,83 @@
+package gotimeout
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+//the log helpers are only called with a Logger set, so an unconfigured Timeout never builds attributes
+
+//logError logs a reported error, a panic as an error and a dropped or abandoned callback as a warning
+//id is that of the callback with TraceCallbacks, 0 for an error of no callback in particular
+func (t *Timeout) logError(err error, id uint64) {
+	attrs := []slog.Attr{slog.Any("error", err)}
+	if id != 0 {
+		attrs = append(attrs, slog.Uint64("callback", id))
+	}
+	var panicErr *PanicError
+	if errors.As(err, &panicErr) {
+		t.Logger.LogAttrs(context.Background(), slog.LevelError, "gotimeout: callback panicked",
+			append([]slog.Attr{slog.Any("panic", panicErr.Value)}, attrs...)...,
+		)
+		return
+	}
+	msg := "gotimeout: callback dropped"
+	if errors.Is(err, ErrCallbackTimeout) {
+		msg = "gotimeout: callback abandoned"
+	}
+	t.Logger.LogAttrs(context.Background(), slog.LevelWarn, msg, attrs...)
+}
+
+//logFire logs an entry that fired, with the ids of its callbacks with TraceCallbacks
+func (t *Timeout) logFire(d time.Duration, callbacks int, ids []uint64) {
+	attrs := []slog.Attr{
+		slog.Duration("timeout", d),
+		slog.Int("callbacks", callbacks),
+	}
+	if ids != nil {
+		attrs = append(attrs, slog.Any("ids", ids))
+	}
+	t.Logger.LogAttrs(context.Background(), slog.LevelDebug, "gotimeout: entry fired", attrs...)
+}
+
+//logStop logs Stop and DrainAndStop, with the number of entries and callbacks they dropped or drained
+func (t *Timeout) logStop(drain bool, entries int) {
+	t.Logger.LogAttrs(context.Background(), slog.LevelInfo, "gotimeout: stopped",
+		slog.Bool("drain", drain),
+		slog.Int("entries", entries),
+		slog.Int64("callbacks", t.stats.pendingCallbacks.Load()),
+	)
+}
+
+//tracing tells if callbacks get an id, TraceCallbacks without a Logger has nowhere to log them
+func (t *Timeout) tracing() bool {
+	return t.TraceCallbacks && t.Logger != nil
+}
+
+//trace gives a callback its id before it joins an entry, a callback placed again keeps the id it got first
+func (t *Timeout) trace(slot *callbackSlot) {
+	if slot.traceID == 0 {
+		slot.traceID = t.traceSeq.Add(1)
+	}
+}
+
+//traceIDs returns the ids of traced callbacks, for the event and the log of the entry they fired from
+func traceIDs(callbacks []*callbackSlot) []uint64 {
+	ids := make([]uint64, 0, len(callbacks))
+	for _, slot := range callbacks {
+		if slot.traceID != 0 {
+			ids = append(ids, slot.traceID)
+		}
+	}
+	return ids
+}
+
+//logCallback logs a step in the life of a traced callback
+func (t *Timeout) logCallback(msg string, id uint64, d time.Duration) {
+	t.Logger.LogAttrs(context.Background(), slog.LevelDebug, msg,
+		slog.Uint64("callback", id),
+		slog.Duration("timeout", d),
+	)
+}
@@ -0,0 +1,89 @@
+package gotimeout_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestTraceCallbacks(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	to, clock := newFakeTimeout(gotimeout.WithLogger(logger), gotimeout.WithTraceCallbacks())
+	to.AfterFunc(1, func() {})
+	cancel := to.AfterFuncCancellable(1, func() {})
+	cancel()
+	clock.Advance(2 * time.Second)
+
+	var traced []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if i := strings.Index(line, "msg=\"gotimeout: callback "); i >= 0 {
+			traced = append(traced, line[i:])
+		}
+	}
+	want := []string{
+		`msg="gotimeout: callback scheduled" callback=1 timeout=1s`,
+		`msg="gotimeout: callback scheduled" callback=2 timeout=1s`,
+		`msg="gotimeout: callback cancelled" callback=2 timeout=1s`,
+		`msg="gotimeout: callback fired" callback=1 timeout=1s`,
+	}
+	if strings.Join(traced, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("traced\n%s\nwant\n%s", strings.Join(traced, "\n"), strings.Join(want, "\n"))
+	}
+
+	//without TraceCallbacks nothing is traced
+	buf.Reset()
+	untraced, clock := newFakeTimeout(gotimeout.WithLogger(logger))
+	untraced.AfterFunc(1, func() {})
+	clock.Advance(2 * time.Second)
+	if strings.Contains(buf.String(), "gotimeout: callback ") {
+		t.Fatalf("traced without TraceCallbacks: %s", buf.String())
+	}
+}
+
+func TestTraceCallbacksLifecycle(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	to, clock := newFakeTimeout(gotimeout.WithLogger(logger), gotimeout.WithTraceCallbacks())
+	events := to.Events()
+	to.AfterFunc(2, func() {})
+	to.CancelAll(2)
+	to.AfterFunc(3, func() {})
+	to.Extract()
+	token := gotimeout.NewCancelToken()
+	to.AfterFuncToken(4, token, func() {})
+	token.Cancel()
+	to.AfterFuncKeyed(1, "k", func() {})
+	to.AfterFuncKeyed(1, "k", func() { panic("boom") })
+	clock.Advance(time.Second)
+
+	var traced []string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if i := strings.Index(line, "msg=\"gotimeout: callback "); i >= 0 {
+			traced = append(traced, line[i:])
+		}
+	}
+	want := []string{
+		`msg="gotimeout: callback scheduled" callback=1 timeout=2s`,
+		`msg="gotimeout: callback cancelled" callback=1 timeout=2s`,
+		`msg="gotimeout: callback scheduled" callback=2 timeout=3s`,
+		`msg="gotimeout: callback extracted" callback=2 timeout=3s`,
+		`msg="gotimeout: callback scheduled" callback=3 timeout=4s`,
+		`msg="gotimeout: callback cancelled" callback=3 timeout=4s`,
+		`msg="gotimeout: callback scheduled" callback=4 timeout=1s`,
+		`msg="gotimeout: callback cancelled" callback=4 timeout=1s`,
+		`msg="gotimeout: callback scheduled" callback=5 timeout=1s`,
+		`msg="gotimeout: callback fired" callback=5 timeout=1s`,
+		`msg="gotimeout: callback panicked" panic=boom error="gotimeout: callback panicked: boom" callback=5`,
+	}
+	if strings.Join(traced, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("traced\n%s\nwant\n%s", strings.Join(traced, "\n"), strings.Join(want, "\n"))
+	}
+	if event := <-events; len(event.IDs) != 1 || event.IDs[0] != 5 {
+		t.Fatalf("expected the event to carry the id of the callback it ran, got %v", event.IDs)
+	}
+}
@@ -0,0 +1,56 @@
+package gotimeout
+
+//onceState tracks the copies of an AfterFuncOnce key that were scheduled but did not fire yet
+type onceState struct {
+	pending int  //copies still waiting, the key is forgotten once the last one fired
+	fired   bool //the first copy to fire ran its callback, the others are no-ops
+}
+
+func AfterFuncOnce(key string, seconds int, callback TimeoutCallback) {
+	defaultTimeout().AfterFuncOnce(key, seconds, callback)
+}
+
+// AfterFuncOnce works like AfterFunc, but of all the callbacks scheduled for the same key only the first to fire runs
+// e.g. when several code paths schedule the same cleanup with different timeouts, the copies that fire later are no-ops
+// the key is forgotten once all its copies fired, so memory does not grow with the keys used, a key scheduled after that runs again
+// a copy dropped by Stop never fires, its key is then kept until Reset
+func (t *Timeout) AfterFuncOnce(key string, seconds int, callback TimeoutCallback) {
+	t.onceMu.Lock()
+	state := t.onces[key]
+	if state == nil {
+		state = &onceState{}
+		if t.onces == nil {
+			t.onces = make(map[string]*onceState)
+		}
+		t.onces[key] = state
+	}
+	state.pending++
+	t.onceMu.Unlock()
+
+	_, err := t.scheduleSlot(secondsToDuration(seconds), &callbackSlot{callback: func() {
+		if t.onceDone(key, state, true) {
+			callback()
+		}
+	}})
+	if err != nil {
+		//never fires, it must not keep the key alive
+		t.onceDone(key, state, false)
+		t.report(err)
+	}
+}
+
+//onceDone counts a copy of the key as done, fired is false for one that could not be scheduled
+//it returns true for the first copy to fire, which runs the callback
+func (t *Timeout) onceDone(key string, state *onceState, fired bool) bool {
+	t.onceMu.Lock()
+	defer t.onceMu.Unlock()
+	state.pending--
+	if state.pending == 0 && t.onces[key] == state {
+		delete(t.onces, key)
+	}
+	if !fired || state.fired {
+		return false
+	}
+	state.fired = true
+	return true
+}
@@ -0,0 +1,27 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAfterFuncOnce(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var runs []string
+	to.AfterFuncOnce("cleanup", 3, func() { runs = append(runs, "3s") })
+	to.AfterFuncOnce("cleanup", 1, func() { runs = append(runs, "1s") })
+	to.AfterFuncOnce("cleanup", 2, func() { runs = append(runs, "2s") })
+	to.AfterFuncOnce("other", 2, func() { runs = append(runs, "other") })
+	clock.Advance(3 * time.Second)
+	if len(runs) != 2 || runs[0] != "1s" || runs[1] != "other" {
+		t.Fatalf("runs = %v, want only the first copy of each key", runs)
+	}
+
+	//all copies fired, the key starts over
+	runs = nil
+	to.AfterFuncOnce("cleanup", 1, func() { runs = append(runs, "again") })
+	clock.Advance(time.Second)
+	if len(runs) != 1 || runs[0] != "again" {
+		t.Fatalf("runs = %v, a key whose copies all fired did not start over", runs)
+	}
+}
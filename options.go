@@ -0,0 +1,256 @@
+package gotimeout
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Option configures a Timeout created by NewTimeout
+type Option func(*Timeout)
+
+// WithCacheWindow sets how long an entry is reused before a new one is created
+func WithCacheWindow(window time.Duration) Option {
+	return func(t *Timeout) {
+		t.CacheWindow = window
+	}
+}
+
+// WithMaxEntryAge caps how old an entry may be to still be joined, see MaxEntryAge
+func WithMaxEntryAge(age time.Duration) Option {
+	return func(t *Timeout) {
+		t.MaxEntryAge = age
+	}
+}
+
+// WithMaxSeconds sets the longest timeout that is cached, see Fallback for what happens to longer timeouts
+func WithMaxSeconds(seconds int) Option {
+	return func(t *Timeout) {
+		t.MaxSeconds = seconds
+	}
+}
+
+// WithClock sets the source of time, used to control time in tests
+func WithClock(clock Clock) Option {
+	return func(t *Timeout) {
+		t.Clock = clock
+	}
+}
+
+// WithTimerFunc arms all timers through f instead of the Clock, e.g. to drive a Timeout from a simulation
+func WithTimerFunc(f func(d time.Duration, f func()) (stop func())) Option {
+	return func(t *Timeout) {
+		t.TimerFunc = f
+	}
+}
+
+// WithTimingWheel arms all timers through a single hierarchical timing wheel advanced every tick
+// instead of one runtime timer per entry, timers fire up to one tick late
+// the wheel runs on a real ticker, also when a Clock is set, the tick must be positive
+func WithTimingWheel(tick time.Duration) Option {
+	return func(t *Timeout) {
+		t.wheel = newWheel(tick)
+	}
+}
+
+// WithFineWheel coalesces timeouts up to horizon by the tick they are due in, e.g. 1ms ticks for a 100ms horizon
+// callbacks due in the same tick share an entry whatever their timeout length, and fire up to one tick late
+// longer timeouts keep using the cached buckets, Jitter does not apply to the fine wheel
+// the wheel runs on a real ticker that wakes up every tick while it holds entries, also when a Clock is set
+// it is bypassed when a TimerFunc is set, which then arms the timers of the cached buckets instead
+// so a 1ms tick costs about a thousand wakeups per second under load, and nothing once the ring is empty
+// the tick must be positive and the horizon at least a tick
+func WithFineWheel(tick, horizon time.Duration) Option {
+	return func(t *Timeout) {
+		t.fine = newFineWheel(tick, horizon)
+	}
+}
+
+// WithCallbackTimeout abandons callbacks running longer than d, so a callback that blocks does not stall its entry
+func WithCallbackTimeout(d time.Duration) Option {
+	return func(t *Timeout) {
+		t.CallbackTimeout = d
+	}
+}
+
+// WithDedupCallbacks skips callbacks whose function is already waiting in the same entry, see DedupCallbacks
+func WithDedupCallbacks() Option {
+	return func(t *Timeout) {
+		t.DedupCallbacks = true
+	}
+}
+
+// WithPanicPolicy sets what happens when a callback panics, see PanicPolicy
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(t *Timeout) {
+		t.PanicPolicy = policy
+	}
+}
+
+// WithStrict rejects scheduling from within a callback of the same Timeout, see Strict
+func WithStrict() Option {
+	return func(t *Timeout) {
+		t.Strict = true
+	}
+}
+
+// WithOrderByDeadline runs the callbacks of an entry in the order they were meant to fire, see OrderByDeadline
+func WithOrderByDeadline() Option {
+	return func(t *Timeout) {
+		t.OrderByDeadline = true
+	}
+}
+
+// WithMeasureCallbacks times every callback into Stats, see MeasureCallbacks
+func WithMeasureCallbacks() Option {
+	return func(t *Timeout) {
+		t.MeasureCallbacks = true
+	}
+}
+
+// WithTestingHooks sets hooks for tests to observe triggers, callbacks and cancels, see TestingHooks
+func WithTestingHooks(hooks *TestingHooks) Option {
+	return func(t *Timeout) {
+		t.Hooks = hooks
+	}
+}
+
+// WithRounding sets how durations are mapped to buckets, see Rounding
+func WithRounding(mode RoundingMode) Option {
+	return func(t *Timeout) {
+		t.Rounding = mode
+	}
+}
+
+// WithExecutionOrder sets the order the callbacks of an entry run in, see ExecutionOrder
+func WithExecutionOrder(order ExecutionOrder) Option {
+	return func(t *Timeout) {
+		t.ExecutionOrder = order
+	}
+}
+
+// WithTracer sets the Tracer told about every entry that fires
+func WithTracer(tracer Tracer) Option {
+	return func(t *Timeout) {
+		t.Tracer = tracer
+	}
+}
+
+// WithCoalesce lets callbacks join an entry of a length up to seconds shorter or longer than their own, see Coalesce
+func WithCoalesce(seconds int) Option {
+	return func(t *Timeout) {
+		t.Coalesce = seconds
+	}
+}
+
+// WithMaxCallbacksPerBucket bounds the number of callbacks an entry batches, see MaxCallbacksPerBucket
+func WithMaxCallbacksPerBucket(n int) Option {
+	return func(t *Timeout) {
+		t.MaxCallbacksPerBucket = n
+	}
+}
+
+// WithMaxLongEntries bounds the number of lengths beyond MaxSeconds that are coalesced, see MaxLongEntries
+func WithMaxLongEntries(n int) Option {
+	return func(t *Timeout) {
+		t.MaxLongEntries = n
+	}
+}
+
+// WithFallback sets what happens to timeouts beyond MaxSeconds, see Fallback
+func WithFallback(fallback Fallback) Option {
+	return func(t *Timeout) {
+		t.Fallback = fallback
+	}
+}
+
+// WithLogger logs panics, dropped callbacks, fired entries and Stop through logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(t *Timeout) {
+		t.Logger = logger
+	}
+}
+
+// WithTraceCallbacks logs every callback with an id when it is scheduled, fires and is cancelled, see TraceCallbacks
+func WithTraceCallbacks() Option {
+	return func(t *Timeout) {
+		t.TraceCallbacks = true
+	}
+}
+
+// WithFairDispatch runs the callbacks of all entries on a single goroutine, taking turns between ready entries, see FairDispatch
+func WithFairDispatch() Option {
+	return func(t *Timeout) {
+		t.FairDispatch = true
+	}
+}
+
+// WithSerialDispatch runs the callbacks of all entries on a single goroutine, see SerialDispatch
+func WithSerialDispatch() Option {
+	return func(t *Timeout) {
+		t.SerialDispatch = true
+	}
+}
+
+// WithJitter offsets the fire time of every new entry by a random amount within [-jitter, +jitter]
+func WithJitter(jitter time.Duration) Option {
+	return func(t *Timeout) {
+		t.Jitter = jitter
+	}
+}
+
+// WithOnCreate sets a hook called whenever an entry is created, as there was none to join
+func WithOnCreate(onCreate func(timeout time.Duration)) Option {
+	return func(t *Timeout) {
+		t.OnCreate = onCreate
+	}
+}
+
+// WithOnEmpty sets a hook called when cancellations empty an entry before it fires, see OnEmpty
+func WithOnEmpty(onEmpty func(timeout time.Duration)) Option {
+	return func(t *Timeout) {
+		t.OnEmpty = onEmpty
+	}
+}
+
+// WithPersistence sets the hooks AfterFuncPersistent journals its callbacks through, see OnSchedule and Restore
+func WithPersistence(onSchedule func(id uint64, fireAt time.Time), onFire func(id uint64)) Option {
+	return func(t *Timeout) {
+		t.OnSchedule = onSchedule
+		t.OnFire = onFire
+	}
+}
+
+// WithOnTrigger sets a hook called once per entry when it fires, with the number of callbacks it batched
+func WithOnTrigger(onTrigger func(timeout time.Duration, count int)) Option {
+	return func(t *Timeout) {
+		t.OnTrigger = onTrigger
+	}
+}
+
+// WithGranularity sets the size of a cache bucket, durations are rounded to the nearest multiple of it
+func WithGranularity(granularity time.Duration) Option {
+	return func(t *Timeout) {
+		t.Granularity = granularity
+	}
+}
+
+// WithWorkers dispatches the callbacks of a fired entry to a pool of the given number of goroutines
+func WithWorkers(workers int) Option {
+	return func(t *Timeout) {
+		t.Workers = workers
+	}
+}
+
+// WithErrorHandler sets a handler for problems that would otherwise go unnoticed, such as panicking callbacks
+func WithErrorHandler(handler func(error)) Option {
+	return func(t *Timeout) {
+		t.ErrorHandler = handler
+	}
+}
+
+// WithMaxPendingCallbacks limits the number of callbacks waiting in entries, to shed load instead of growing without bound
+func WithMaxPendingCallbacks(n int) Option {
+	return func(t *Timeout) {
+		t.MaxPendingCallbacks = n
+	}
+}
@@ -0,0 +1,59 @@
+package gotimeout_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestPanicPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy gotimeout.PanicPolicy
+		ran    []int
+	}{
+		{gotimeout.PanicRecover, []int{1, 3}},
+		{gotimeout.PanicStop, []int{1}},
+	} {
+		var errs []error
+		to, clock := newFakeTimeout(gotimeout.WithPanicPolicy(tc.policy),
+			gotimeout.WithErrorHandler(func(err error) { errs = append(errs, err) }))
+		var ran []int
+		to.AfterFunc(1, func() { ran = append(ran, 1) })
+		to.AfterFunc(1, func() { panic("boom") })
+		to.AfterFunc(1, func() { ran = append(ran, 3) })
+		clock.Advance(time.Second)
+
+		var panicErr *gotimeout.PanicError
+		if len(errs) != 1 || !errors.As(errs[0], &panicErr) || panicErr.Value != "boom" {
+			t.Fatalf("policy %v reported %v, want the panic", tc.policy, errs)
+		}
+		if !slices.Equal(ran, tc.ran) {
+			t.Fatalf("policy %v ran %v, want %v", tc.policy, ran, tc.ran)
+		}
+		if s := to.Stats(); s.Pending != 0 || s.Fired != int64(len(tc.ran)+1) {
+			t.Fatalf("policy %v Stats %+v", tc.policy, s)
+		}
+	}
+}
+
+func TestPanicPropagate(t *testing.T) {
+	var errs []error
+	to, clock := newFakeTimeout(gotimeout.WithPanicPolicy(gotimeout.PanicPropagate),
+		gotimeout.WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	to.AfterFunc(1, func() { panic("boom") })
+
+	//the fake clock runs the callback within Advance, so that is where it panics again
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("recovered %v, want the callback's panic", r)
+		}
+		if len(errs) != 1 {
+			t.Fatalf("reported %v, want the panic reported before propagating", errs)
+		}
+	}()
+	clock.Advance(time.Second)
+	t.Fatal("Advance returned after a propagated panic")
+}
@@ -0,0 +1,40 @@
+package gotimeout
+
+// Pause holds back every entry that comes due until Resume, no callbacks are lost
+// timers keep running while paused, an entry that comes due is held and fires on Resume, those that do not stay untouched
+// callbacks can still be scheduled while paused, Wait and WaitTimeout keep waiting for held entries
+// zero timeouts do not go through a timer, they still run right away
+func (t *Timeout) Pause() {
+	t.pauseMu.Lock()
+	t.paused = true
+	t.pauseMu.Unlock()
+}
+
+// Resume fires every entry that came due while paused right away, and returns without waiting for their callbacks
+// paused over timeouts do not keep their offsets, they all fire at once, the remaining ones fire at their own deadline
+// each held entry fires on a goroutine of its own like its timer would have, so they run concurrently, SerialDispatch runs them by deadline
+func (t *Timeout) Resume() {
+	t.pauseMu.Lock()
+	held := t.held
+	t.paused = false
+	t.held = nil
+	t.pauseMu.Unlock()
+
+	for _, entry := range held {
+		//never on the caller's goroutine, a slow callback only holds up its own entry, one stopped while held does not trigger
+		go t.fire(entry)
+	}
+}
+
+//hold keeps back an entry that came due while paused, false if it should fire right away
+func (t *Timeout) hold(entry *timeoutEntry) bool {
+	t.pauseMu.Lock()
+	defer t.pauseMu.Unlock()
+	if !t.paused {
+		return false
+	}
+	//new callbacks must not join an entry that is already late, they go into a fresh one
+	t.release(entry)
+	t.held = append(t.held, entry)
+	return true
+}
@@ -0,0 +1,55 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauseHoldsEntries(t *testing.T) {
+	to, clock := newFakeTimeout()
+	fired := make(chan struct{})
+	to.AfterFunc(1, func() { close(fired) })
+	later := make(chan struct{})
+	to.AfterFunc(5, func() { close(later) })
+	to.Pause()
+	clock.Advance(2 * time.Second)
+	select {
+	case <-fired:
+		t.Fatal("an entry fired while paused")
+	default:
+	}
+	to.Resume()
+	waitFor(t, fired, "held callback")
+	select {
+	case <-later:
+		t.Fatal("an entry that was not due yet fired on Resume")
+	default:
+	}
+	clock.Advance(3 * time.Second)
+	waitFor(t, later, "callback due after Resume")
+}
+
+func TestResumeDoesNotBlock(t *testing.T) {
+	to, clock := newFakeTimeout()
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	to.AfterFunc(1, func() {
+		close(started)
+		<-release
+	})
+	other := make(chan struct{})
+	to.AfterFunc(2, func() { close(other) })
+	to.Pause()
+	clock.Advance(3 * time.Second)
+
+	resumed := make(chan struct{})
+	go func() {
+		to.Resume()
+		close(resumed)
+	}()
+	waitFor(t, resumed, "Resume")
+	waitFor(t, started, "slow held callback")
+	//the slow callback holds up neither Resume nor the other held entry
+	waitFor(t, other, "other held callback")
+}
@@ -0,0 +1,86 @@
+package gotimeout
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PersistedTimeout is a timeout of AfterFuncPersistent as journaled through OnSchedule, to be handed to Restore after a restart
+// a func cannot be stored, so Callback is left out of JSON and given again for Restore, e.g. looked up by what the caller stored along with ID
+type PersistedTimeout struct {
+	ID       uint64          `json:"id"`     //id OnSchedule was called with
+	FireAt   time.Time       `json:"fireAt"` //when the timeout was due
+	Callback TimeoutCallback `json:"-"`      //what to run, supplied by the caller on Restore
+}
+
+func AfterFuncPersistent(seconds int, callback TimeoutCallback) uint64 {
+	return defaultTimeout().AfterFuncPersistent(seconds, callback)
+}
+
+// AfterFuncPersistent works like AfterFunc, but calls OnSchedule with an id and the time it is due, and OnFire with the id once it ran
+// this lets the caller journal pending timeouts and replay them with Restore, the Timeout itself keeps nothing beyond memory
+// OnFire is called after the callback returned, so a crash while it runs replays it, callbacks should be safe to run twice
+// ids count up from 1, and past the ids given to Restore, 0 means the callback could not be scheduled and OnSchedule was not called
+func (t *Timeout) AfterFuncPersistent(seconds int, callback TimeoutCallback) uint64 {
+	id := t.persistSeq.Add(1)
+	d := secondsToDuration(seconds)
+	fireAt := t.now().Add(d)
+	//journaled only once scheduled, so the journal never holds a callback that will not run
+	//a callback firing right away waits for OnSchedule to return before calling OnFire, so OnFire never comes first
+	journaled := make(chan struct{})
+	if _, err := t.scheduleSlot(d, t.persistentSlot(id, callback, journaled)); err != nil {
+		t.report(err)
+		return 0
+	}
+	if onSchedule := t.OnSchedule; onSchedule != nil {
+		onSchedule(id, fireAt)
+	}
+	close(journaled)
+	return id
+}
+
+// Restore schedules the persisted timeouts again after a restart, each for the time left until its FireAt
+// a timeout that was due while the process was down runs right away, the others work like AfterDuration for the time left
+// OnSchedule is not called again, the timeouts are journaled already, OnFire is called for them as for any AfterFuncPersistent
+// it returns the problems it had, an entry without a Callback or one that could not be scheduled is skipped, the others are restored
+func (t *Timeout) Restore(entries []PersistedTimeout) error {
+	var errs []error
+	now := t.now()
+	for _, entry := range entries {
+		t.restoredID(entry.ID)
+		if entry.Callback == nil {
+			errs = append(errs, fmt.Errorf("gotimeout: persisted timeout %d has no callback", entry.ID))
+			continue
+		}
+		if _, err := t.scheduleSlot(max(entry.FireAt.Sub(now), 0), t.persistentSlot(entry.ID, entry.Callback, nil)); err != nil {
+			errs = append(errs, fmt.Errorf("gotimeout: persisted timeout %d: %w", entry.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+//persistentSlot wraps the callback of a persisted timeout to call OnFire once it returned and journaled is closed, nil for one journaled already
+func (t *Timeout) persistentSlot(id uint64, callback TimeoutCallback, journaled <-chan struct{}) *callbackSlot {
+	slot := t.userSlot(callback)
+	slot.callback = func() {
+		callback()
+		if journaled != nil {
+			<-journaled
+		}
+		if onFire := t.OnFire; onFire != nil {
+			onFire(id)
+		}
+	}
+	return slot
+}
+
+//restoredID makes sure AfterFuncPersistent hands out ids beyond id, so a restored id is never reused
+func (t *Timeout) restoredID(id uint64) {
+	for {
+		last := t.persistSeq.Load()
+		if last >= id || t.persistSeq.CompareAndSwap(last, id) {
+			return
+		}
+	}
+}
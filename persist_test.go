@@ -0,0 +1,131 @@
+package gotimeout_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+	"github.com/asynkron/gotimeout/gotimeouttest"
+)
+
+func TestPersistAndRestore(t *testing.T) {
+	var mu sync.Mutex
+	journal := map[uint64]time.Time{}
+	persistence := gotimeout.WithPersistence(
+		func(id uint64, fireAt time.Time) {
+			mu.Lock()
+			journal[id] = fireAt
+			mu.Unlock()
+		},
+		func(id uint64) {
+			mu.Lock()
+			delete(journal, id)
+			mu.Unlock()
+		})
+	ran := make(chan string, 8)
+	callbacks := map[string]gotimeout.TimeoutCallback{}
+	names := map[uint64]string{}
+	for _, name := range []string{"short", "medium", "long"} {
+		name := name
+		callbacks[name] = func() { ran <- name }
+	}
+
+	to, clock := newFakeTimeout(persistence)
+	names[to.AfterFuncPersistent(1, callbacks["short"])] = "short"
+	names[to.AfterFuncPersistent(5, callbacks["medium"])] = "medium"
+	names[to.AfterFuncPersistent(10, callbacks["long"])] = "long"
+	clock.Advance(2 * time.Second)
+	if got := <-ran; got != "short" || len(journal) != 2 {
+		t.Fatalf("expected short to run and be forgotten, ran %s with %d journaled", got, len(journal))
+	}
+
+	//the process restarts 7s in, medium was due during the downtime, long has 3s left
+	restarted := gotimeouttest.NewFakeClock(clock.Now().Add(5 * time.Second))
+	after := gotimeout.MustNewTimeout(gotimeout.WithClock(restarted), persistence)
+	var entries []gotimeout.PersistedTimeout
+	for id, fireAt := range journal {
+		entries = append(entries, gotimeout.PersistedTimeout{ID: id, FireAt: fireAt, Callback: callbacks[names[id]]})
+	}
+	entries = append(entries, gotimeout.PersistedTimeout{ID: 9})
+	if err := after.Restore(entries); err == nil || errors.Is(err, gotimeout.ErrStopped) {
+		t.Fatalf("expected Restore to report the entry without a callback, got %v", err)
+	}
+	waitFor(t, chanDone(ran, "medium"), "overdue restored callback")
+	restarted.Advance(2 * time.Second)
+	select {
+	case got := <-ran:
+		t.Fatalf("%s ran before its deadline", got)
+	default:
+	}
+	restarted.Advance(time.Second)
+	if got := <-ran; got != "long" {
+		t.Fatalf("expected long to run at its deadline, ran %s", got)
+	}
+	mu.Lock()
+	left := len(journal)
+	mu.Unlock()
+	if left != 0 {
+		t.Fatalf("expected the journal to be empty, %d left", left)
+	}
+	//new ids continue past the restored ones
+	if id := after.AfterFuncPersistent(1, func() {}); id != 10 {
+		t.Fatalf("expected id 10 after restoring up to 9, got %d", id)
+	}
+}
+
+//chanDone closes the returned channel once want was received from ran
+func chanDone(ran <-chan string, want string) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		for got := range ran {
+			if got == want {
+				close(done)
+				return
+			}
+		}
+	}()
+	return done
+}
+
+func TestPersistentNotJournaledWhenRejected(t *testing.T) {
+	var journaled []uint64
+	to, _ := newFakeTimeout(gotimeout.WithPersistence(
+		func(id uint64, _ time.Time) { journaled = append(journaled, id) },
+		func(uint64) {}))
+	to.Stop()
+	if id := to.AfterFuncPersistent(1, func() {}); id != 0 {
+		t.Fatalf("expected id 0 after Stop, got %d", id)
+	}
+	if len(journaled) != 0 {
+		t.Fatalf("expected OnSchedule not to be called for a rejected callback, got %v", journaled)
+	}
+}
+
+func TestPersistentImmediateFiresAfterJournal(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	fired := make(chan struct{})
+	to, _ := newFakeTimeout(gotimeout.WithPersistence(
+		func(uint64, time.Time) {
+			//give the callback running right away time to finish first
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			events = append(events, "scheduled")
+			mu.Unlock()
+		},
+		func(uint64) {
+			mu.Lock()
+			events = append(events, "fired")
+			mu.Unlock()
+			close(fired)
+		}))
+	to.AfterFuncPersistent(0, func() {})
+	waitFor(t, fired, "OnFire")
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 || events[0] != "scheduled" {
+		t.Fatalf("expected OnSchedule before OnFire, got %v", events)
+	}
+}
@@ -0,0 +1,75 @@
+package gotimeout
+
+import "sync"
+
+//workerPool runs callbacks on a fixed number of goroutines
+//submitting blocks while all workers are busy, so a huge entry never spawns more goroutines than that
+type workerPool struct {
+	tasks    chan func()
+	quit     chan struct{} //each receive retires one worker, see resize
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu      sync.Mutex
+	workers int
+}
+
+func newWorkerPool(workers int) *workerPool {
+	p := &workerPool{
+		tasks: make(chan func()),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	p.resize(workers)
+	return p
+}
+
+func (p *workerPool) work() {
+	for {
+		select {
+		case task := <-p.tasks:
+			task()
+		case <-p.quit:
+			return
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *workerPool) submit(task func()) {
+	select {
+	case p.tasks <- task:
+	case <-p.done:
+		//the pool is gone, run on the caller instead of blocking forever
+		task()
+	}
+}
+
+//resize starts or retires workers until there are n
+//a worker is only retired in between tasks, the retirement is handed off so resize never waits for busy workers
+//waiting would deadlock a resize from within a callback, whose worker can only become idle once resize returned
+func (p *workerPool) resize(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for ; p.workers < n; p.workers++ {
+		go p.work()
+	}
+	for ; p.workers > n; p.workers-- {
+		go p.retire()
+	}
+}
+
+//retire stops the next worker that goes idle, a worker started by a later resize may be the one to go, the count stays right
+func (p *workerPool) retire() {
+	select {
+	case p.quit <- struct{}{}:
+	case <-p.done:
+	}
+}
+
+func (p *workerPool) stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+	})
+}
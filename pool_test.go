@@ -0,0 +1,62 @@
+package gotimeout_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestSetWorkerCountFromCallbacks(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithWorkers(2))
+	var running sync.WaitGroup
+	running.Add(2)
+	done := make(chan struct{}, 2)
+	shrink := func() {
+		//both workers are busy by the time either of them shrinks the pool
+		running.Done()
+		running.Wait()
+		to.SetWorkerCount(1)
+		done <- struct{}{}
+	}
+	to.AfterFuncBatch(1, []gotimeout.TimeoutCallback{shrink, shrink})
+	clock.Advance(time.Second)
+	for i := 0; i < 2; i++ {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("SetWorkerCount from within a callback deadlocked")
+		}
+	}
+
+	//the single remaining worker still runs callbacks
+	ran := make(chan struct{})
+	to.AfterFunc(1, func() { close(ran) })
+	clock.Advance(time.Second)
+	waitFor(t, ran, "callback after shrinking")
+}
+
+func TestSetWorkerCountWhileFiring(t *testing.T) {
+	to := gotimeout.MustNewTimeout(gotimeout.WithWorkers(2), gotimeout.WithGranularity(10*time.Millisecond))
+	defer to.Stop()
+	const n = 300
+	var fired atomic.Int64
+	for i := 0; i < n; i++ {
+		to.AfterDuration(time.Duration(i%30+1)*10*time.Millisecond, func() {
+			time.Sleep(time.Millisecond)
+			fired.Add(1)
+		})
+	}
+	for _, workers := range []int{8, 1, 0, 3} {
+		time.Sleep(50 * time.Millisecond)
+		to.SetWorkerCount(workers)
+	}
+	if !to.WaitTimeout(5 * time.Second) {
+		t.Fatalf("%d of %d callbacks fired", fired.Load(), n)
+	}
+	if got := fired.Load(); got != n {
+		t.Fatalf("%d of %d callbacks fired", got, n)
+	}
+}
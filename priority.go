@@ -0,0 +1,37 @@
+package gotimeout
+
+import (
+	"cmp"
+	"slices"
+)
+
+func AfterFuncPriority(seconds int, priority int, callback TimeoutCallback) {
+	defaultTimeout().AfterFuncPriority(seconds, priority, callback)
+}
+
+// AfterFuncPriority works like AfterFunc, but callbacks of a higher priority run before those of a lower one in the same entry
+// callbacks of equal priority keep ExecutionOrder, those scheduled with plain AfterFunc have priority 0
+// an entry that got a prioritized callback sorts its callbacks when it fires, which costs O(n log n) for n callbacks
+// entries without one are not sorted, so plain AfterFunc pays nothing for this
+// with Workers the callbacks are started by priority, but may still finish in any order
+func (t *Timeout) AfterFuncPriority(seconds int, priority int, callback TimeoutCallback) {
+	slot := t.userSlot(callback)
+	slot.priority = priority
+	t.scheduleOrReport(secondsToDuration(seconds), slot)
+}
+
+//sortByPriority orders the callbacks of a fired entry by priority, highest first
+//the sort is stable, so callbacks of equal priority stay in ExecutionOrder
+func sortByPriority(callbacks []*callbackSlot) {
+	slices.SortStableFunc(callbacks, func(a, b *callbackSlot) int {
+		return cmp.Compare(b.priority, a.priority)
+	})
+}
+
+//sortByDeadline orders the callbacks of a fired entry by when they were meant to fire, earliest first
+//the sort is stable, so callbacks due at the same instant stay in ExecutionOrder
+func sortByDeadline(callbacks []*callbackSlot) {
+	slices.SortStableFunc(callbacks, func(a, b *callbackSlot) int {
+		return a.deadline.Compare(b.deadline)
+	})
+}
@@ -0,0 +1,54 @@
+package gotimeout_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestAfterFuncPriority(t *testing.T) {
+	for _, tc := range []struct {
+		order gotimeout.ExecutionOrder
+		want  []string
+	}{
+		{gotimeout.FIFO, []string{"flush", "audit", "log1", "log2", "trace"}},
+		{gotimeout.LIFO, []string{"flush", "audit", "log2", "log1", "trace"}},
+	} {
+		to, clock := newFakeTimeout(gotimeout.WithExecutionOrder(tc.order))
+		var ran []string
+		record := func(name string) gotimeout.TimeoutCallback {
+			return func() { ran = append(ran, name) }
+		}
+		to.AfterFunc(1, record("log1"))
+		to.AfterFuncPriority(1, -1, record("trace"))
+		to.AfterFuncPriority(1, 10, record("flush"))
+		to.AfterFunc(1, record("log2"))
+		to.AfterFuncPriority(1, 5, record("audit"))
+		clock.Advance(time.Second)
+		if !slices.Equal(ran, tc.want) {
+			t.Fatalf("order %v ran %v, want %v", tc.order, ran, tc.want)
+		}
+	}
+}
+
+func TestOrderByDeadline(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithOrderByDeadline(), gotimeout.WithExecutionOrder(gotimeout.LIFO))
+	var ran []string
+	record := func(name string) gotimeout.TimeoutCallback {
+		return func() { ran = append(ran, name) }
+	}
+	//all of them round to the 1s bucket and share its entry
+	to.AfterDuration(time.Second, record("on time"))
+	to.AfterDuration(1040*time.Millisecond, record("late"))
+	to.AfterDuration(960*time.Millisecond, record("early"))
+	to.AfterFuncPriority(1, 1, record("urgent"))
+	if got := to.Stats().CacheMisses; got != 1 {
+		t.Fatalf("expected the callbacks to share one entry, got %d", got)
+	}
+	clock.Advance(2 * time.Second)
+	if want := []string{"urgent", "early", "on time", "late"}; !slices.Equal(ran, want) {
+		t.Fatalf("ran %v, want %v", ran, want)
+	}
+}
@@ -0,0 +1,123 @@
+package gotimeout
+
+import (
+	"sync"
+	"time"
+)
+
+func AfterFuncRetry(seconds int, attempts int, callback func() error) {
+	defaultTimeout().AfterFuncRetry(seconds, attempts, callback)
+}
+
+// AfterFuncRetry works like AfterFunc, but if the callback returns an error it is scheduled again for the same timeout
+// up to attempts times, so the callback runs at most attempts+1 times
+// every retry is scheduled like a new callback, so it lands in a fresh entry and coalesces like any other callback
+func (t *Timeout) AfterFuncRetry(seconds int, attempts int, callback func() error) {
+	d := secondsToDuration(seconds)
+	var retry func(left int)
+	retry = func(left int) {
+		t.reschedule(d, func() {
+			if err := callback(); err != nil && left > 0 {
+				retry(left - 1)
+			}
+		})
+	}
+	retry(attempts)
+}
+
+func EveryFunc(seconds int, callback func() (repeat bool)) {
+	defaultTimeout().EveryFunc(seconds, callback)
+}
+
+// EveryFunc runs the callback every seconds for as long as it returns true, like a ticker sharing the cached timers
+// every run schedules the next one once it returned, so runs never overlap and each lands in a fresh entry
+// the next run is seconds after the previous one fired, give or take the cache window, so the period drifts rather than catching up
+// a zero or negative interval runs the callback once, repeating it right away would spin
+func (t *Timeout) EveryFunc(seconds int, callback func() (repeat bool)) {
+	d := secondsToDuration(seconds)
+	var tick func()
+	tick = func() {
+		if callback() && d > 0 {
+			t.reschedule(d, tick)
+		}
+	}
+	t.reschedule(d, tick)
+}
+
+func Backoff(base time.Duration, factor float64, max time.Duration, callback func() (retry bool)) {
+	defaultTimeout().Backoff(base, factor, max, callback)
+}
+
+// Backoff runs the callback after base, and again for as long as it returns true, every interval factor times the previous one up to max
+// e.g. 1s, 2 and 30s retries after 1s, 2s, 4s ... 16s and then every 30s, each interval scheduled like AfterDuration so retries share the cached timers
+// there is no limit on attempts, the callback stops the backoff by returning false, counting attempts itself if it wants a limit
+// a factor below 1 is taken as 1, a max below base as base, and a zero or negative base runs the callback once like EveryFunc
+func (t *Timeout) Backoff(base time.Duration, factor float64, max time.Duration, callback func() (retry bool)) {
+	if factor < 1 {
+		factor = 1
+	}
+	if max < base {
+		max = base
+	}
+	d := base
+	var attempt func()
+	attempt = func() {
+		if !callback() || base <= 0 {
+			return
+		}
+		//compare as floats, so a large factor cannot overflow the duration before it is capped
+		if next := float64(d) * factor; next < float64(max) {
+			d = time.Duration(next)
+		} else {
+			d = max
+		}
+		t.reschedule(d, attempt)
+	}
+	t.reschedule(d, attempt)
+}
+
+func AfterFuncEveryNth(seconds int, n int, callback TimeoutCallback) CancelFunc {
+	return defaultTimeout().AfterFuncEveryNth(seconds, n, callback)
+}
+
+// AfterFuncEveryNth registers the callback for the timeout length until cancelled, and runs it on every nth time it fires
+// e.g. with seconds 1 and n 10 the callback runs about every 10s, sampling a length that fires every second
+// every fire schedules the next one like EveryFunc would, joining the entry of the length like any one-shot callback,
+// so it neither delays the one-shot callbacks it shares an entry with nor keeps an entry for them, and it fires even when they are gone
+// the returned CancelFunc stops it, a run that already started still finishes, n below 1 is taken as 1
+// a zero or negative timeout has no entry that fires again, it schedules nothing
+func (t *Timeout) AfterFuncEveryNth(seconds int, n int, callback TimeoutCallback) CancelFunc {
+	d := secondsToDuration(seconds)
+	if d <= 0 {
+		return noop
+	}
+	n = max(n, 1)
+	var mu sync.Mutex
+	var cancel CancelFunc
+	stopped := false
+	fires := 0
+	var tick func()
+	tick = func() {
+		mu.Lock()
+		if stopped {
+			mu.Unlock()
+			return
+		}
+		//the next fire is scheduled first, so a slow callback does not stretch the period
+		fires++
+		cancel = t.reschedule(d, tick)
+		mu.Unlock()
+		if fires%n == 0 {
+			callback()
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	cancel = t.schedule(d, tick)
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		cancel()
+	}
+}
@@ -0,0 +1,66 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	to, clock := newFakeTimeout()
+	start := clock.Now()
+	var fired []time.Duration
+	to.Backoff(time.Second, 2, 4*time.Second, func() bool {
+		fired = append(fired, clock.Now().Sub(start))
+		return len(fired) < 5
+	})
+	clock.Advance(time.Minute)
+	//the offsets are 1s, +2s, +4s and then capped at +4s, give or take the cache window of each interval
+	want := []time.Duration{time.Second, 3 * time.Second, 7 * time.Second, 11 * time.Second, 15 * time.Second}
+	if len(fired) != len(want) {
+		t.Fatalf("expected %d attempts, got %v", len(want), fired)
+	}
+	for i, w := range want {
+		if fired[i] < w-time.Second || fired[i] > w+time.Second {
+			t.Fatalf("attempt %d fired at %v, expected about %v", i, fired[i], w)
+		}
+	}
+}
+
+func TestBackoffNonPositiveBase(t *testing.T) {
+	to, clock := newFakeTimeout()
+	done := make(chan struct{})
+	runs := 0
+	to.Backoff(0, 2, time.Second, func() bool {
+		runs++
+		close(done)
+		return true
+	})
+	waitFor(t, done, "backoff")
+	clock.Advance(time.Minute)
+	if runs != 1 {
+		t.Fatalf("expected a single run, got %d", runs)
+	}
+}
+
+func TestAfterFuncEveryNth(t *testing.T) {
+	to, clock := newFakeTimeout()
+	runs := 0
+	stop := to.AfterFuncEveryNth(1, 3, func() { runs++ })
+	//a one-shot callback sharing the entry fires as usual
+	oneShot := false
+	to.AfterFunc(1, func() { oneShot = true })
+	for i := 0; i < 9; i++ {
+		clock.Advance(time.Second)
+	}
+	if runs != 3 || !oneShot {
+		t.Fatalf("expected 3 runs in 9 fires and the one-shot callback to fire, got %d", runs)
+	}
+	stop()
+	clock.Advance(10 * time.Second)
+	if runs != 3 {
+		t.Fatalf("expected no runs after stop, got %d", runs)
+	}
+	if s := to.Stats(); s.Pending != 0 {
+		t.Fatalf("expected nothing left pending, got %d", s.Pending)
+	}
+}
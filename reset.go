@@ -0,0 +1,58 @@
+package gotimeout
+
+//resetHandle is the callback last scheduled for a key, a callback only runs while it is still the current one
+type resetHandle struct {
+	cancel CancelFunc
+}
+
+func AfterFuncReset(key string, seconds int, callback TimeoutCallback) {
+	defaultTimeout().AfterFuncReset(key, seconds, callback)
+}
+
+// AfterFuncReset works like AfterFunc, but cancels the callback previously scheduled for the same key
+// every call resets the timeout of the key, e.g. for idle timeouts or debouncing, only the last callback per key runs
+// concurrent calls for the same key are serialized, the one that comes last wins
+func (t *Timeout) AfterFuncReset(key string, seconds int, callback TimeoutCallback) {
+	t.resetMu.Lock()
+	if prev, ok := t.resets[key]; ok {
+		prev.cancel()
+	}
+	if t.resets == nil {
+		t.resets = make(map[string]*resetHandle)
+	}
+
+	h := &resetHandle{}
+	t.resets[key] = h
+	cancel, err := t.scheduleSlot(secondsToDuration(seconds), &callbackSlot{callback: func() {
+		t.resetMu.Lock()
+		//the entry may have taken the callback right before it was cancelled, a newer schedule still wins then
+		current := t.resets[key] == h
+		if current {
+			delete(t.resets, key)
+		}
+		t.resetMu.Unlock()
+		if current {
+			callback()
+		}
+	}})
+	if err != nil {
+		delete(t.resets, key)
+		t.resetMu.Unlock()
+		t.report(err)
+		return
+	}
+	h.cancel = cancel
+	t.resetMu.Unlock()
+}
+
+func Debounce(key string, seconds int, callback TimeoutCallback) {
+	defaultTimeout().Debounce(key, seconds, callback)
+}
+
+// Debounce runs the callback once calls for the key stopped for the timeout, e.g. to flush a buffer once activity quiets down
+// a burst of calls only runs the callback passed last, seconds after the last call
+// it is AfterFuncReset under another name, so both share their keys, a Debounce cancels a pending AfterFuncReset of the key and vice versa
+// keys of AfterFuncKeyed are unrelated, they only dedupe callbacks within an entry and never cancel a Debounce
+func (t *Timeout) Debounce(key string, seconds int, callback TimeoutCallback) {
+	t.AfterFuncReset(key, seconds, callback)
+}
@@ -0,0 +1,43 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDebounce(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var runs []int
+	//a burst of calls, 300ms apart, only runs the last callback, a second after the last call
+	for i := 0; i < 5; i++ {
+		i := i
+		to.Debounce("flush", 1, func() { runs = append(runs, i) })
+		clock.Advance(300 * time.Millisecond)
+	}
+	if len(runs) != 0 {
+		t.Fatalf("debounced callbacks ran during the burst: %v", runs)
+	}
+	clock.Advance(700 * time.Millisecond)
+	if len(runs) != 1 || runs[0] != 4 {
+		t.Fatalf("runs = %v, want only the last callback", runs)
+	}
+	clock.Advance(10 * time.Second)
+	if len(runs) != 1 {
+		t.Fatalf("runs = %v, a cancelled callback ran late", runs)
+	}
+}
+
+func TestDebounceKeys(t *testing.T) {
+	to, clock := newFakeTimeout()
+	ran := map[string]int{}
+	to.Debounce("a", 1, func() { ran["a"]++ })
+	to.Debounce("b", 1, func() { ran["b"]++ })
+	//Debounce and AfterFuncReset share their keys, the later one cancels the other
+	to.AfterFuncReset("b", 2, func() { ran["reset"]++ })
+	//keys of AfterFuncKeyed are unrelated
+	to.AfterFuncKeyed(1, "a", func() { ran["keyed"]++ })
+	clock.Advance(2 * time.Second)
+	if ran["a"] != 1 || ran["b"] != 0 || ran["reset"] != 1 || ran["keyed"] != 1 {
+		t.Fatalf("ran = %v", ran)
+	}
+}
@@ -0,0 +1,74 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestRounding(t *testing.T) {
+	const unique = time.Duration(-1)
+	for _, c := range []struct {
+		mode gotimeout.RoundingMode
+		d    time.Duration
+		want time.Duration
+	}{
+		{gotimeout.RoundNearest, 250 * time.Millisecond, 300 * time.Millisecond},
+		{gotimeout.RoundNearest, 249 * time.Millisecond, 200 * time.Millisecond},
+		{gotimeout.RoundNearest, 50 * time.Millisecond, 100 * time.Millisecond},
+		{gotimeout.RoundNearest, 49 * time.Millisecond, unique},
+		{gotimeout.RoundFloor, 299 * time.Millisecond, 200 * time.Millisecond},
+		{gotimeout.RoundFloor, 300 * time.Millisecond, 300 * time.Millisecond},
+		{gotimeout.RoundFloor, 100 * time.Millisecond, 100 * time.Millisecond},
+		{gotimeout.RoundFloor, 99 * time.Millisecond, unique},
+		{gotimeout.RoundCeil, 201 * time.Millisecond, 300 * time.Millisecond},
+		{gotimeout.RoundCeil, 200 * time.Millisecond, 200 * time.Millisecond},
+		{gotimeout.RoundCeil, time.Millisecond, 100 * time.Millisecond},
+	} {
+		to, _ := newFakeTimeout(gotimeout.WithRounding(c.mode), gotimeout.WithMaxSeconds(5))
+		to.AfterDuration(c.d, func() {})
+		got := unique
+		if to.Stats().UniqueTimers == 0 {
+			if d, ok := bucketOf(to); ok {
+				got = d
+			}
+		}
+		if got != c.want {
+			t.Errorf("mode %v put %v in %v, want %v", c.mode, c.d, got, c.want)
+		}
+	}
+}
+
+//bucketOf returns the length of the only entry of to
+func bucketOf(to *gotimeout.Timeout) (time.Duration, bool) {
+	if buckets := to.Snapshot(); len(buckets) == 1 {
+		return buckets[0].Timeout, true
+	}
+	return 0, false
+}
+
+func TestRoundingBeyondMaxSeconds(t *testing.T) {
+	//beyond MaxSeconds whole seconds are rounded the same way, the entry fires after the rounded length
+	for _, c := range []struct {
+		mode gotimeout.RoundingMode
+		d    time.Duration
+		want time.Duration
+	}{
+		{gotimeout.RoundNearest, 7500 * time.Millisecond, 8 * time.Second},
+		{gotimeout.RoundFloor, 7999 * time.Millisecond, 7 * time.Second},
+		{gotimeout.RoundCeil, 7001 * time.Millisecond, 8 * time.Second},
+	} {
+		to, clock := newFakeTimeout(gotimeout.WithRounding(c.mode), gotimeout.WithMaxSeconds(5))
+		fired := false
+		to.AfterDuration(c.d, func() { fired = true })
+		clock.Advance(c.want - time.Millisecond)
+		if fired {
+			t.Errorf("mode %v fired %v before %v", c.mode, c.d, c.want)
+		}
+		clock.Advance(time.Millisecond)
+		if !fired {
+			t.Errorf("mode %v did not fire %v at %v", c.mode, c.d, c.want)
+		}
+	}
+}
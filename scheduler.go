@@ -0,0 +1,75 @@
+package gotimeout
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Scheduler is the part of a Timeout code can depend on to schedule callbacks, so tests can inject a fake
+// *Timeout implements it, as does whatever SetDefault was given
+type Scheduler interface {
+	AfterFunc(seconds int, callback TimeoutCallback)
+	AfterDuration(d time.Duration, callback TimeoutCallback)
+	AfterFuncCancellable(seconds int, callback TimeoutCallback) CancelFunc
+	AfterFuncContext(ctx context.Context, seconds int, callback TimeoutCallback)
+	AfterFuncCtx(seconds int, callback func(context.Context))
+	TryAfterFunc(seconds int, callback TimeoutCallback) error
+}
+
+var _ Scheduler = (*Timeout)(nil)
+
+//scheduler boxes a Scheduler, atomic.Value needs every Store to be of the same concrete type
+type scheduler struct {
+	Scheduler
+}
+
+var defaultScheduler atomic.Value
+
+//builtin is the Timeout behind the package level functions, ResetDefault replaces it
+var builtin atomic.Pointer[Timeout]
+
+//defaultTimeout returns the built in Timeout, created on first use
+func defaultTimeout() *Timeout {
+	if t := builtin.Load(); t != nil {
+		return t
+	}
+	//a Timeout that loses the race was never used, it holds no timers
+	builtin.CompareAndSwap(nil, MustNewTimeout())
+	return builtin.Load()
+}
+
+// Shutdown stops the built in Timeout behind the package level functions like Stop, a main that wants a clean exit can call it
+// callbacks waiting in its entries are dropped, and the package level functions using it schedule nothing from then on, until ResetDefault
+// a Scheduler set with SetDefault is not stopped: the functions of Scheduler keep delegating to it and schedule as it does, only the
+// other package level functions are stopped, callbacks that already started keep running, Shutdown does not wait for them
+func Shutdown() {
+	defaultTimeout().Stop()
+}
+
+// ResetDefault replaces the built in Timeout with a fresh one and undoes SetDefault, e.g. between tests sharing the package level functions
+// the previous Timeout is stopped like Shutdown would, its callbacks that already started keep running and may still finish after it returns
+func ResetDefault() {
+	defaultScheduler.Store(scheduler{})
+	if previous := builtin.Swap(MustNewTimeout()); previous != nil {
+		previous.Stop()
+	}
+}
+
+// Default returns the Scheduler the package level functions of its methods delegate to, the built in Timeout unless SetDefault replaced it
+func Default() Scheduler {
+	if s, ok := defaultScheduler.Load().(scheduler); ok && s.Scheduler != nil {
+		return s.Scheduler
+	}
+	return defaultTimeout()
+}
+
+// SetDefault makes the package level AfterFunc, AfterDuration, AfterFuncCancellable, AfterFuncContext, AfterFuncCtx and TryAfterFunc
+// delegate to s, and returns the Scheduler they delegated to before, nil restores the built in Timeout
+// the other package level functions keep using the built in Timeout, they are not part of Scheduler
+// e.g. in a test: defer gotimeout.SetDefault(gotimeout.SetDefault(fake))
+func SetDefault(s Scheduler) Scheduler {
+	previous := Default()
+	defaultScheduler.Store(scheduler{s})
+	return previous
+}
@@ -0,0 +1,101 @@
+package gotimeout_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+//recordingScheduler is a fake Scheduler that runs nothing and records the timeouts it was asked for
+type recordingScheduler struct {
+	gotimeout.Scheduler
+	seconds []int
+}
+
+func (r *recordingScheduler) AfterFunc(seconds int, _ gotimeout.TimeoutCallback) {
+	r.seconds = append(r.seconds, seconds)
+}
+
+func (r *recordingScheduler) AfterFuncCtx(seconds int, _ func(context.Context)) {
+	r.seconds = append(r.seconds, seconds)
+}
+
+func TestSetDefault(t *testing.T) {
+	fake := &recordingScheduler{}
+	previous := gotimeout.SetDefault(fake)
+	gotimeout.AfterFunc(3, func() { t.Error("the fake should not run callbacks") })
+	gotimeout.AfterFuncCtx(4, func(context.Context) { t.Error("the fake should not run callbacks") })
+	if len(fake.seconds) != 2 || fake.seconds[0] != 3 || fake.seconds[1] != 4 {
+		t.Fatalf("expected the fake to be asked for 3 and 4, got %v", fake.seconds)
+	}
+	if gotimeout.SetDefault(previous) != fake {
+		t.Fatal("expected SetDefault to return the fake it replaces")
+	}
+
+	//a Timeout can be the default as well, and nil restores the built in one
+	to, clock := newFakeTimeout()
+	gotimeout.SetDefault(to)
+	fired := false
+	gotimeout.AfterFunc(1, func() { fired = true })
+	clock.Advance(2 * time.Second)
+	if !fired {
+		t.Fatal("expected the callback to run on the injected Timeout")
+	}
+	gotimeout.SetDefault(nil)
+	if gotimeout.Default() != previous {
+		t.Fatal("expected nil to restore the built in Timeout")
+	}
+}
+
+func TestShutdownAndResetDefault(t *testing.T) {
+	defer gotimeout.ResetDefault()
+	gotimeout.ResetDefault()
+	fired := make(chan struct{})
+	gotimeout.AfterDuration(50*time.Millisecond, func() { close(fired) })
+	gotimeout.Shutdown()
+	if err := gotimeout.TryAfterFunc(1, func() {}); !errors.Is(err, gotimeout.ErrStopped) {
+		t.Fatalf("expected ErrStopped after Shutdown, got %v", err)
+	}
+	select {
+	case <-fired:
+		t.Fatal("a callback waiting in an entry ran after Shutdown")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	//ResetDefault brings the package level functions back, and undoes SetDefault
+	gotimeout.SetDefault(&recordingScheduler{})
+	gotimeout.ResetDefault()
+	if _, ok := gotimeout.Default().(*gotimeout.Timeout); !ok {
+		t.Fatalf("expected the built in Timeout after ResetDefault, got %T", gotimeout.Default())
+	}
+	done := make(chan struct{})
+	if err := gotimeout.TryAfterFunc(0, func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, done, "callback after ResetDefault")
+}
+
+func TestShutdownWithSetDefault(t *testing.T) {
+	defer gotimeout.ResetDefault()
+	gotimeout.ResetDefault()
+	to, clock := newFakeTimeout()
+	gotimeout.SetDefault(to)
+	gotimeout.Shutdown()
+
+	//the Scheduler set with SetDefault is left alone, the functions of Scheduler still schedule on it
+	fired := false
+	if err := gotimeout.TryAfterFunc(1, func() { fired = true }); err != nil {
+		t.Fatalf("expected the injected Timeout to keep scheduling, got %v", err)
+	}
+	clock.Advance(2 * time.Second)
+	if !fired {
+		t.Fatal("expected the callback to run on the injected Timeout")
+	}
+	//the other package level functions use the built in Timeout, which is stopped
+	if result := gotimeout.AfterFuncResult(1, func() {}); result != gotimeout.ScheduleRejected {
+		t.Fatalf("expected the built in Timeout to reject after Shutdown, got %v", result)
+	}
+}
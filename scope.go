@@ -0,0 +1,100 @@
+package gotimeout
+
+import "sync"
+
+// Scope is a callback scheduled with AfterFuncScope or Derive, the root or a node of a tree of timeouts cancelled together
+// once a Scope fires, or is cancelled, every Scope derived from it that has not fired yet is cancelled, all the way down
+type Scope struct {
+	t        *Timeout
+	callback TimeoutCallback
+	parent   *Scope
+
+	mu       sync.Mutex
+	done     bool //fired or cancelled
+	cancel   CancelFunc
+	children map[*Scope]struct{}
+}
+
+func AfterFuncScope(seconds int, callback TimeoutCallback) *Scope {
+	return defaultTimeout().AfterFuncScope(seconds, callback)
+}
+
+// AfterFuncScope works like AfterFunc, but returns a Scope to derive child timeouts from, e.g. for a request and the calls it makes
+// when it fires the children are cancelled first, then the callback runs, a nil callback just cancels the children
+func (t *Timeout) AfterFuncScope(seconds int, callback TimeoutCallback) *Scope {
+	s := &Scope{t: t, callback: callback}
+	s.schedule(seconds)
+	return s
+}
+
+// Derive schedules a child of the Scope, it is cancelled with the Scope unless it fires first
+// a child shorter than its parent fires on its own, cancelling only its own children, one that is longer never fires
+// as the parent cancels it when it fires, deriving from a Scope that already fired or was cancelled schedules nothing
+func (s *Scope) Derive(seconds int, callback TimeoutCallback) *Scope {
+	child := &Scope{t: s.t, callback: callback, parent: s}
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		child.done = true
+		return child
+	}
+	if s.children == nil {
+		s.children = make(map[*Scope]struct{})
+	}
+	s.children[child] = struct{}{}
+	s.mu.Unlock()
+	child.schedule(seconds)
+	return child
+}
+
+// Cancel cancels the Scope and every Scope derived from it, it returns false if the Scope already fired or was cancelled
+// the callbacks of cancelled scopes never run
+func (s *Scope) Cancel() bool {
+	if !s.finish() {
+		return false
+	}
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return true
+}
+
+//schedule arms the Scope, unless its parent cancelled it while it was being derived
+func (s *Scope) schedule(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return
+	}
+	s.cancel = s.t.schedule(secondsToDuration(seconds), func() {
+		if s.finish() && s.callback != nil {
+			s.callback()
+		}
+	})
+}
+
+//finish marks the Scope done, cancels its children and detaches it from its parent, false if it was done already
+func (s *Scope) finish() bool {
+	s.mu.Lock()
+	if s.done {
+		s.mu.Unlock()
+		return false
+	}
+	s.done = true
+	children := s.children
+	s.children = nil
+	s.mu.Unlock()
+
+	for child := range children {
+		child.Cancel()
+	}
+	if p := s.parent; p != nil {
+		p.mu.Lock()
+		delete(p.children, s)
+		p.mu.Unlock()
+	}
+	return true
+}
@@ -0,0 +1,46 @@
+package gotimeout_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestScope(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var fired []string
+	record := func(name string) func() {
+		return func() { fired = append(fired, name) }
+	}
+	parent := to.AfterFuncScope(5, record("parent"))
+	parent.Derive(2, record("short child"))
+	long := parent.Derive(10, record("long child"))
+	long.Derive(3, record("grandchild"))
+	long.Derive(20, record("long grandchild"))
+
+	clock.Advance(time.Minute)
+	if want := []string{"short child", "grandchild", "parent"}; !slices.Equal(fired, want) {
+		t.Fatalf("fired %v, want %v", fired, want)
+	}
+	if long.Cancel() || parent.Cancel() {
+		t.Fatal("Cancel succeeded on a scope that was cancelled or fired")
+	}
+	if late := parent.Derive(1, record("late")); late.Cancel() {
+		t.Fatal("Derive from a scope that fired scheduled a child")
+	}
+
+	fired = nil
+	parent = to.AfterFuncScope(5, record("parent"))
+	child := parent.Derive(1, record("child"))
+	child.Derive(2, record("grandchild"))
+	if !parent.Cancel() {
+		t.Fatal("Cancel of a pending scope failed")
+	}
+	clock.Advance(time.Minute)
+	if len(fired) != 0 || child.Cancel() {
+		t.Fatalf("fired %v after cancelling the root", fired)
+	}
+	if s := to.Stats(); s.Pending != 0 {
+		t.Fatalf("Stats %+v, want the whole tree cancelled", s)
+	}
+}
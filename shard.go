@@ -0,0 +1,132 @@
+package gotimeout
+
+import "sync"
+
+//entryShardCount is the number of shards an entry spreads concurrent joins over
+const entryShardCount = 8
+
+//entryShard buffers callbacks that joined an entry, goroutines joining at once take different shards rather than queueing on one lock
+//its callbacks are gathered into timeoutEntry.callbacks whenever the entry is locked for writing, so nothing else has to know about shards
+type entryShard struct {
+	sync.Mutex
+	callbacks []*callbackSlot
+	_         [64 - 32]byte //keeps neighbouring shards apart on their own cache line
+}
+
+type entryShards [entryShardCount]entryShard
+
+//shardable tells if the slot can join through a shard
+//keyed and deduplicated callbacks, priorities and MaxCallbacksPerBucket need the entry as a whole, so they take the entry lock
+func (te *timeoutEntry) shardable(slot *callbackSlot) bool {
+	return slot.key == "" && slot.code == 0 && slot.priority == 0 && !te.unique && te.owner.MaxCallbacksPerBucket <= 0
+}
+
+//joinShard is join for a shardable slot that found the entry locked, it only holds the entry lock for reading
+//the first shard that is not locked takes the slot, so the shards are only created and spread over once there is contention
+func (te *timeoutEntry) joinShard(slot *callbackSlot) joinResult {
+	te.RLock()
+	defer te.RUnlock()
+	if te.stopped {
+		return joinStopped
+	}
+	if te.completed {
+		return joinCompleted
+	}
+	shards := te.shards.Load()
+	if shards == nil {
+		shards = new(entryShards)
+		if !te.shards.CompareAndSwap(nil, shards) {
+			shards = te.shards.Load()
+		}
+	}
+	shard := &shards[0]
+	if !shard.TryLock() {
+		shard = nil
+		for i := 1; i < len(shards) && shard == nil; i++ {
+			if shards[i].TryLock() {
+				shard = &shards[i]
+			}
+		}
+		if shard == nil {
+			shard = &shards[0]
+			shard.Lock()
+		}
+	}
+	//taken under the shard lock, so every shard is in order and gather only has to merge them
+	slot.seq = te.seq.Add(1)
+	if shard.callbacks == nil {
+		shard.callbacks = getCallbacks()
+	}
+	shard.callbacks = append(shard.callbacks, slot)
+	shard.Unlock()
+	te.owner.stats.pendingCallbacks.Add(1)
+	return joinAdded
+}
+
+//gather moves the callbacks buffered in the shards to the end of callbacks, in the order they joined, the entry must be locked for writing
+//all of them joined after those already in callbacks, as whatever appends to callbacks gathers first
+func (te *timeoutEntry) gather() {
+	shards := te.shards.Load()
+	if shards == nil {
+		return
+	}
+	filled, only := 0, 0
+	for i := range shards {
+		if len(shards[i].callbacks) > 0 {
+			filled, only = filled+1, i
+		}
+	}
+	if filled == 0 {
+		return
+	}
+	if filled == 1 && len(te.callbacks) == 0 {
+		//the common case without contention, the shard's slice becomes the entry's as it is
+		te.callbacks, shards[only].callbacks = shards[only].callbacks, te.callbacks
+		for i, slot := range te.callbacks {
+			slot.index = i
+		}
+		te.live += len(te.callbacks)
+		return
+	}
+	heads := [entryShardCount]int{}
+	for {
+		next := -1
+		for i := range shards {
+			if heads[i] < len(shards[i].callbacks) && (next < 0 || shards[i].callbacks[heads[i]].seq < shards[next].callbacks[heads[next]].seq) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		slot := shards[next].callbacks[heads[next]]
+		heads[next]++
+		if te.callbacks == nil {
+			te.callbacks = getCallbacks()
+		}
+		slot.index = len(te.callbacks)
+		te.callbacks = append(te.callbacks, slot)
+		te.live++
+	}
+	for i := range shards {
+		if heads[i] > 0 {
+			//keep the slice for later joins, its slots now belong to callbacks
+			clear(shards[i].callbacks)
+			shards[i].callbacks = shards[i].callbacks[:0]
+		}
+	}
+}
+
+//releaseShards hands the buffers of a completed entry back to the pool, the entry must be locked for writing and gathered
+func (te *timeoutEntry) releaseShards() {
+	shards := te.shards.Load()
+	if shards == nil {
+		return
+	}
+	for i := range shards {
+		if shards[i].callbacks != nil {
+			putCallbacks(shards[i].callbacks)
+			shards[i].callbacks = nil
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package gotimeout
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJoinThroughShards(t *testing.T) {
+	to := MustNewTimeout()
+	te := to.newEntry(time.Second)
+	//holding the entry for reading makes every join find it locked, so they all go through the shards
+	te.RLock()
+	var slots []*callbackSlot
+	join := func(n int) {
+		for i := 0; i < n; i++ {
+			slot := &callbackSlot{callback: func() {}}
+			if _, result := te.join(slot); result != joinAdded {
+				t.Fatalf("join = %v", result)
+			}
+			slots = append(slots, slot)
+		}
+	}
+	join(3)
+	shards := te.shards.Load()
+	//locked shards are skipped, spreading the next joins over the others
+	shards[0].Lock()
+	join(3)
+	shards[1].Lock()
+	join(3)
+	shards[0].Unlock()
+	shards[1].Unlock()
+	join(3)
+	te.RUnlock()
+	if len(shards[0].callbacks) != 6 || len(shards[1].callbacks) != 3 || len(shards[2].callbacks) != 3 {
+		t.Fatalf("shards hold %d, %d and %d callbacks", len(shards[0].callbacks), len(shards[1].callbacks), len(shards[2].callbacks))
+	}
+
+	//removing a callback gathers them first, in the order they joined
+	if !te.removeSlot(slots[4]) {
+		t.Fatal("removeSlot did not find a callback that joined through a shard")
+	}
+	if te.live != len(slots)-1 || len(te.callbacks) != len(slots) {
+		t.Fatalf("%d live of %d gathered callbacks, want %d of %d", te.live, len(te.callbacks), len(slots)-1, len(slots))
+	}
+	for i, slot := range te.callbacks {
+		if i == 4 {
+			if slot != nil {
+				t.Fatal("removed callback left no hole")
+			}
+			continue
+		}
+		if slot != slots[i] || slot.index != i {
+			t.Fatalf("gathered callback %d is out of order", i)
+		}
+	}
+	//callbacks that join after gathering go after the gathered ones
+	te.RLock()
+	join(1)
+	te.RUnlock()
+	te.Lock()
+	te.gather()
+	te.Unlock()
+	if last := te.callbacks[len(te.callbacks)-1]; last != slots[len(slots)-1] || last.index != len(slots)-1 {
+		t.Fatal("callback joined after gathering is not last")
+	}
+}
@@ -0,0 +1,48 @@
+package gotimeout
+
+import (
+	"sync"
+	"time"
+)
+
+// BucketState is shared by the callbacks scheduled with AfterFuncShared that went into the same entry
+// Timeout, Created and Deadline are set once and only read, Value belongs to the callbacks
+// callbacks of an entry run one after the other by default, but with Workers or CallbackTimeout they may overlap
+// so hold the lock while accessing Value
+type BucketState struct {
+	sync.Mutex
+	Timeout  time.Duration //the timeout length of the entry
+	Created  time.Time     //when the entry was created
+	Deadline time.Time     //when the entry was due to fire
+	Value    interface{}   //free for the callbacks to use, e.g. as an accumulator
+}
+
+func AfterFuncShared(seconds int, callback func(shared *BucketState)) {
+	defaultTimeout().AfterFuncShared(seconds, callback)
+}
+
+// AfterFuncShared works like AfterFunc, but the callback receives the BucketState of the entry it fired with
+// the state is created once per entry, so callbacks that were coalesced see the same one and can aggregate across the batch
+// a callback that runs right away, or got a unique timer, has a state of its own
+func (t *Timeout) AfterFuncShared(seconds int, callback func(shared *BucketState)) {
+	t.scheduleOrReport(secondsToDuration(seconds), &callbackSlot{entryCallback: func(te *timeoutEntry) {
+		callback(t.bucketState(te))
+	}})
+}
+
+//bucketState returns the state of the entry, creating it if this is the first callback to ask for it
+func (t *Timeout) bucketState(te *timeoutEntry) *BucketState {
+	if te == nil {
+		now := t.now()
+		return &BucketState{Created: now, Deadline: now}
+	}
+	if state := te.shared.Load(); state != nil {
+		return state
+	}
+	//timestamp and deadline no longer change once the entry fired
+	state := &BucketState{Timeout: te.timeout, Created: te.timestamp, Deadline: te.deadline}
+	if te.shared.CompareAndSwap(nil, state) {
+		return state
+	}
+	return te.shared.Load()
+}
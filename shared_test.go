@@ -0,0 +1,59 @@
+package gotimeout_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestAfterFuncShared(t *testing.T) {
+	to, clock := newFakeTimeout()
+	start := clock.Now()
+	var states []*gotimeout.BucketState
+	count := func(shared *gotimeout.BucketState) {
+		shared.Lock()
+		defer shared.Unlock()
+		n, _ := shared.Value.(int)
+		shared.Value = n + 1
+		states = append(states, shared)
+	}
+	to.AfterFuncShared(1, count)
+	clock.Advance(100 * time.Millisecond)
+	to.AfterFuncShared(1, count)
+	to.AfterFuncShared(2, count)
+	clock.Advance(2 * time.Second)
+
+	if len(states) != 3 || states[0] != states[1] || states[0] == states[2] {
+		t.Fatalf("states %v, want the two coalesced callbacks to share one", states)
+	}
+	shared := states[0]
+	if shared.Value != 2 || shared.Timeout != time.Second || !shared.Created.Equal(start) || !shared.Deadline.Equal(start.Add(time.Second)) {
+		t.Fatalf("shared state %+v", shared)
+	}
+}
+
+func TestAfterFuncSharedWorkers(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithWorkers(4))
+	const n = 100
+	var wg sync.WaitGroup
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		to.AfterFuncShared(1, func(shared *gotimeout.BucketState) {
+			defer wg.Done()
+			shared.Lock()
+			defer shared.Unlock()
+			total, _ := shared.Value.(int)
+			shared.Value = total + 1
+		})
+	}
+	var last *gotimeout.BucketState
+	to.AfterFuncShared(1, func(shared *gotimeout.BucketState) { last = shared })
+	clock.Advance(time.Second)
+	wg.Wait()
+	to.Wait()
+	if last == nil || last.Value != n {
+		t.Fatalf("shared state %+v, want every callback counted", last)
+	}
+}
@@ -0,0 +1,49 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignalAfter(t *testing.T) {
+	to, clock := newFakeTimeout()
+	ch := make(chan struct{}, 1)
+	to.SignalAfter(1, ch)
+	to.SignalAfter(1, ch)
+	clock.Advance(time.Second)
+	select {
+	case <-ch:
+	default:
+		t.Fatal("no signal once the timeout fired")
+	}
+	//the second send found the buffer full and was dropped rather than blocking the entry
+	select {
+	case <-ch:
+		t.Fatal("a full channel got a second signal")
+	default:
+	}
+}
+
+//BenchmarkSignalAfter compares to BenchmarkClosureSignal, SignalAfter saves allocating the closure that captures ch
+func BenchmarkSignalAfter(b *testing.B) {
+	to, _ := newFakeTimeout()
+	ch := make(chan struct{}, 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		to.SignalAfter(10, ch)
+	}
+}
+
+func BenchmarkClosureSignal(b *testing.B) {
+	to, _ := newFakeTimeout()
+	ch := make(chan struct{}, 1)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		to.AfterFunc(10, func() {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package gotimeout
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the counters of a Timeout
+type Stats struct {
+	CacheHits     int64 //callbacks that joined an existing entry
+	CacheMisses   int64 //entries created as there was none to join
+	UniqueTimers  int64 //unique timers created, for AfterFuncPrecise or timeouts shorter than half a bucket
+	ActiveEntries int64 //entries with a pending timer, unique ones included
+	Fired         int64 //callbacks that ran
+	Pending       int64 //callbacks waiting in entries
+	DroppedEvents int64 //events not sent as nobody drained the Events channel
+	LongEvictions int64 //lengths beyond MaxSeconds evicted to stay within MaxLongEntries
+	Overflows     int64 //callbacks that found their entry at MaxCallbacksPerBucket and spilled to a fresh one
+	TimersSaved   int64 //callbacks that joined an entry with a timer already running, each one a time.AfterFunc avoided
+
+	//with MeasureCallbacks, zero otherwise
+	Measured      int64         //callbacks that were timed
+	CallbackTotal time.Duration //time spent running them
+	CallbackMax   time.Duration //the longest any of them took
+}
+
+type stats struct {
+	cacheHits        atomic.Int64
+	cacheMisses      atomic.Int64
+	uniqueTimers     atomic.Int64
+	activeEntries    atomic.Int64
+	fired            atomic.Int64
+	pendingCallbacks atomic.Int64
+	droppedEvents    atomic.Int64
+	longEvictions    atomic.Int64
+	overflows        atomic.Int64
+	timersSaved      atomic.Int64
+	measured         atomic.Int64
+	callbackTotal    atomic.Int64
+	callbackMax      atomic.Int64
+}
+
+// Stats returns the current counters, reading them never blocks scheduling
+func (t *Timeout) Stats() Stats {
+	return Stats{
+		CacheHits:     t.stats.cacheHits.Load(),
+		CacheMisses:   t.stats.cacheMisses.Load(),
+		UniqueTimers:  t.stats.uniqueTimers.Load(),
+		ActiveEntries: t.stats.activeEntries.Load(),
+		Fired:         t.stats.fired.Load(),
+		Pending:       t.stats.pendingCallbacks.Load(),
+		DroppedEvents: t.stats.droppedEvents.Load(),
+		LongEvictions: t.stats.longEvictions.Load(),
+		Overflows:     t.stats.overflows.Load(),
+		TimersSaved:   t.stats.timersSaved.Load(),
+		Measured:      t.stats.measured.Load(),
+		CallbackTotal: time.Duration(t.stats.callbackTotal.Load()),
+		CallbackMax:   time.Duration(t.stats.callbackMax.Load()),
+	}
+}
+
+//measure adds a callback that ran for took to the aggregates of MeasureCallbacks
+func (s *stats) measure(took time.Duration) {
+	s.measured.Add(1)
+	s.callbackTotal.Add(int64(took))
+	for {
+		longest := s.callbackMax.Load()
+		if int64(took) <= longest || s.callbackMax.CompareAndSwap(longest, int64(took)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package gotimeout
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//firing holds the ids of the goroutines running a callback with Strict
+type firing struct {
+	goroutines sync.Map
+}
+
+//goroutineID parses the id of the calling goroutine from its stack header, "goroutine 42 [running]:"
+//it is slow next to scheduling, so it is only used with Strict
+func goroutineID() uint64 {
+	var buf [64]byte
+	header := buf[:runtime.Stack(buf[:], false)]
+	header = bytes.TrimPrefix(header, []byte("goroutine "))
+	if i := bytes.IndexByte(header, ' '); i >= 0 {
+		header = header[:i]
+	}
+	id, _ := strconv.ParseUint(string(header), 10, 64)
+	return id
+}
+
+//fireSlot runs the callback of the slot, or queues it with AfterFuncThrottle beyond the rate, releaseThrottled runs it later on
+func (t *Timeout) fireSlot(te *timeoutEntry, slot *callbackSlot) {
+	if slot.throttle.rate > 0 && !t.admit(te, slot) {
+		return
+	}
+	t.runSlot(te, slot)
+}
+
+//runSlot runs the callback of the slot, with Strict the goroutine counts as firing until it returns
+//with MeasureCallbacks it is timed as well, a panic is timed too, up to the point it recovered
+func (t *Timeout) runSlot(te *timeoutEntry, slot *callbackSlot) {
+	if t.Hooks != nil {
+		var timeout time.Duration
+		if te != nil {
+			timeout = te.timeout
+		}
+		t.Hooks.ran(timeout)
+	}
+	if slot.traceID != 0 {
+		var timeout time.Duration
+		if te != nil {
+			timeout = te.timeout
+		}
+		t.logCallback("gotimeout: callback fired", slot.traceID, timeout)
+	}
+	if t.MeasureCallbacks {
+		start := time.Now()
+		defer func() { t.stats.measure(time.Since(start)) }()
+	}
+	if !t.Strict {
+		slot.fire(te)
+		return
+	}
+	id := goroutineID()
+	t.firing.goroutines.Store(id, struct{}{})
+	defer t.firing.goroutines.Delete(id)
+	slot.fire(te)
+}
+
+//reentrant tells if the calling goroutine is running a callback of t, always false without Strict
+func (t *Timeout) reentrant() bool {
+	if !t.Strict {
+		return false
+	}
+	_, ok := t.firing.goroutines.Load(goroutineID())
+	return ok
+}
+
+//reschedule is schedule for the repeating variants, which schedule their next run from the callback of the last one
+func (t *Timeout) reschedule(d time.Duration, callback TimeoutCallback) CancelFunc {
+	return t.scheduleOrReport(d, &callbackSlot{callback: callback, repeat: true})
+}
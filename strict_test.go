@@ -0,0 +1,96 @@
+package gotimeout_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestStrictRejectsReentrant(t *testing.T) {
+	var reported []error
+	to, clock := newFakeTimeout(gotimeout.WithStrict(), gotimeout.WithErrorHandler(func(err error) {
+		reported = append(reported, err)
+	}))
+	var tried error
+	to.AfterFunc(1, func() {
+		tried = to.TryAfterFunc(1, func() {})
+		to.AfterFunc(1, func() {})
+		to.AfterFuncBatch(1, []gotimeout.TimeoutCallback{func() {}, func() {}})
+	})
+	clock.Advance(2 * time.Second)
+	if !errors.Is(tried, gotimeout.ErrReentrant) {
+		t.Fatalf("expected ErrReentrant from TryAfterFunc, got %v", tried)
+	}
+	if len(reported) != 2 || !errors.Is(reported[0], gotimeout.ErrReentrant) || !errors.Is(reported[1], gotimeout.ErrReentrant) {
+		t.Fatalf("expected ErrReentrant to be reported, got %v", reported)
+	}
+	//outside of a callback scheduling still works
+	if err := to.TryAfterFunc(1, func() {}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStrictAllowsRepeating(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithStrict())
+	runs := 0
+	to.EveryFunc(1, func() bool {
+		runs++
+		return runs < 3
+	})
+	clock.Advance(10 * time.Second)
+	if runs != 3 {
+		t.Fatalf("expected EveryFunc to run 3 times, got %d", runs)
+	}
+}
+
+func TestReentrantWithoutStrict(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var tried error
+	fired := false
+	to.AfterFunc(1, func() {
+		tried = to.TryAfterFunc(1, func() { fired = true })
+	})
+	clock.Advance(5 * time.Second)
+	if tried != nil || !fired {
+		t.Fatalf("expected re-entrant scheduling to work, got %v", tried)
+	}
+}
+
+func TestStrictRejectsJoinExisting(t *testing.T) {
+	var reported []error
+	to, clock := newFakeTimeout(gotimeout.WithStrict(), gotimeout.WithErrorHandler(func(err error) {
+		reported = append(reported, err)
+	}))
+	to.AfterFunc(2, func() {})
+	joined := true
+	to.AfterFunc(1, func() { joined = to.JoinExisting(2, func() {}) })
+	clock.Advance(time.Second)
+	if joined || len(reported) != 1 || !errors.Is(reported[0], gotimeout.ErrReentrant) {
+		t.Fatalf("expected JoinExisting from a callback to report ErrReentrant, joined %v and reported %v", joined, reported)
+	}
+}
+
+func TestStrictCoversThrottled(t *testing.T) {
+	var reported []error
+	ran := 0
+	hooks := &gotimeout.TestingHooks{Ran: func(time.Duration) { ran++ }}
+	to, clock := newFakeTimeout(gotimeout.WithStrict(), gotimeout.WithMeasureCallbacks(), gotimeout.WithTestingHooks(hooks),
+		gotimeout.WithErrorHandler(func(err error) { reported = append(reported, err) }))
+	for i := 0; i < 2; i++ {
+		to.AfterFuncThrottle(1, 1, func() { to.AfterFunc(1, func() {}) })
+	}
+	clock.Advance(time.Second)
+	if ran != 1 {
+		t.Fatalf("expected the queued callback not to run yet, Ran was called %d times", ran)
+	}
+	//the second callback is released a second later, it runs like any other
+	clock.Advance(time.Second)
+	if ran != 2 || to.Stats().Measured != 2 {
+		t.Fatalf("expected both callbacks to run and be measured, Ran %d times and %d measured", ran, to.Stats().Measured)
+	}
+	if len(reported) != 2 || !errors.Is(reported[1], gotimeout.ErrReentrant) {
+		t.Fatalf("expected the released callback to be rejected with ErrReentrant, got %v", reported)
+	}
+}
@@ -0,0 +1,122 @@
+package gotimeout
+
+import "time"
+
+//throttleKey identifies the callbacks of AfterFuncThrottle released together, those of one length and rate
+type throttleKey struct {
+	seconds int
+	rate    int
+}
+
+//throttle releases the callbacks of a burst at most rate per second
+type throttle struct {
+	released int          //callbacks run within the current second
+	queue    []queuedSlot //callbacks that fired beyond the rate, released by the next seconds
+	timer    Timer        //releases the next second, stopped by Reset and Stop
+}
+
+//queuedSlot is a throttled callback waiting for its second, with the entry it fired from
+type queuedSlot struct {
+	te   *timeoutEntry
+	slot *callbackSlot
+}
+
+func AfterFuncThrottle(seconds int, ratePerSec int, callback TimeoutCallback) {
+	defaultTimeout().AfterFuncThrottle(seconds, ratePerSec, callback)
+}
+
+// AfterFuncThrottle works like AfterFunc, but of the callbacks for the same length and rate at most ratePerSec run per second
+// e.g. when thousands of callbacks share an entry and each calls the same downstream, they are released at the rate rather than all at once
+// the first ratePerSec callbacks of a burst run when their entry fires, the rest are queued and released ratePerSec at a time every second after
+// so a throttled callback runs up to n/ratePerSec seconds late for a burst of n callbacks, on top of CacheWindow
+// callbacks of other lengths or rates are not held up by the queue, Idle waits for queued callbacks, a zero or negative rate does not throttle
+// Reset and Stop drop the queued callbacks, DrainAndStop runs them right away, once stopped callbacks that still fire are not throttled
+func (t *Timeout) AfterFuncThrottle(seconds int, ratePerSec int, callback TimeoutCallback) {
+	slot := t.userSlot(callback)
+	if ratePerSec > 0 {
+		slot.throttle = throttleKey{seconds: seconds, rate: ratePerSec}
+	}
+	t.scheduleOrReport(secondsToDuration(seconds), slot)
+}
+
+//admit tells if a callback that fired is within the rate and runs right away, otherwise it is queued for the next second
+func (t *Timeout) admit(te *timeoutEntry, slot *callbackSlot) bool {
+	if t.stopped.Load() {
+		//nothing releases a queue after Stop, like any callback of an entry that is firing it runs
+		return true
+	}
+	key := slot.throttle
+	t.throttleMu.Lock()
+	th := t.throttles[key]
+	if th == nil {
+		//first of a burst, the second it counts for starts now
+		th = &throttle{}
+		if t.throttles == nil {
+			t.throttles = make(map[throttleKey]*throttle)
+		}
+		t.throttles[key] = th
+		th.timer = t.timer(time.Second, func() { t.releaseThrottled(key, th) })
+	}
+	if th.released >= key.rate || len(th.queue) > 0 {
+		th.queue = append(th.queue, queuedSlot{te: te, slot: slot})
+		t.pending.add()
+		t.throttleMu.Unlock()
+		return false
+	}
+	th.released++
+	t.throttleMu.Unlock()
+	return true
+}
+
+//releaseThrottled starts the next second of a throttle, running up to rate queued callbacks, a second without any ends the burst
+func (t *Timeout) releaseThrottled(key throttleKey, th *throttle) {
+	t.throttleMu.Lock()
+	if t.throttles[key] != th {
+		//dropped by Reset or Stop
+		t.throttleMu.Unlock()
+		return
+	}
+	n := min(len(th.queue), key.rate)
+	if n == 0 && th.released == 0 {
+		delete(t.throttles, key)
+		t.throttleMu.Unlock()
+		return
+	}
+	queued := th.queue[:n:n]
+	th.queue = th.queue[n:]
+	th.released = n
+	th.timer = t.timer(time.Second, func() { t.releaseThrottled(key, th) })
+	t.throttleMu.Unlock()
+
+	//released callbacks run like any other that fired, with Strict, MeasureCallbacks, Hooks and tracing
+	for _, q := range queued {
+		t.invokeTraced(q.slot.traceID, func() { t.runSlot(q.te, q.slot) })
+		t.pending.done()
+	}
+}
+
+//dropThrottles forgets every throttle and stops its release timer, the queued callbacks are dropped, or run right away with drain
+func (t *Timeout) dropThrottles(drain bool) {
+	t.throttleMu.Lock()
+	var queued []queuedSlot
+	for _, th := range t.throttles {
+		th.timer.Stop()
+		queued = append(queued, th.queue...)
+	}
+	t.throttles = nil
+	t.throttleMu.Unlock()
+	if !drain {
+		for _, q := range queued {
+			t.dropped("gotimeout: callback dropped", secondsToDuration(q.slot.throttle.seconds), q.slot)
+			t.pending.done()
+		}
+		return
+	}
+	//like the callbacks of drained entries, on a goroutine of their own
+	go func() {
+		for _, q := range queued {
+			t.invokeTraced(q.slot.traceID, func() { t.runSlot(q.te, q.slot) })
+			t.pending.done()
+		}
+	}()
+}
@@ -0,0 +1,75 @@
+package gotimeout_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAfterFuncThrottle(t *testing.T) {
+	to, clock := newFakeTimeout()
+	start := clock.Now()
+	var fired []time.Duration
+	for i := 0; i < 7; i++ {
+		to.AfterFuncThrottle(1, 3, func() { fired = append(fired, clock.Now().Sub(start)) })
+	}
+	//another length is not held up by the queue
+	other := false
+	to.AfterFuncThrottle(2, 3, func() { other = true })
+	clock.Advance(time.Second)
+	if len(fired) != 3 {
+		t.Fatalf("expected 3 callbacks within the first second, got %d", len(fired))
+	}
+	clock.Advance(time.Second)
+	if len(fired) != 6 || !other {
+		t.Fatalf("expected 6 callbacks after two seconds and the other length to run, got %d", len(fired))
+	}
+	clock.Advance(time.Second)
+	if len(fired) != 7 {
+		t.Fatalf("expected the burst to drain, got %d", len(fired))
+	}
+	select {
+	case <-to.Idle():
+	default:
+		t.Fatal("expected no callbacks left waiting")
+	}
+	//once drained a later burst starts over at the full rate
+	clock.Advance(5 * time.Second)
+	for i := 0; i < 3; i++ {
+		to.AfterFuncThrottle(1, 3, func() { fired = append(fired, clock.Now().Sub(start)) })
+	}
+	clock.Advance(time.Second)
+	if len(fired) != 10 {
+		t.Fatalf("expected a new burst to run right away, got %d", len(fired))
+	}
+}
+
+func TestThrottleStopAndReset(t *testing.T) {
+	for _, name := range []string{"Stop", "Reset", "DrainAndStop"} {
+		to, clock := newFakeTimeout()
+		var fired atomic.Int32
+		for i := 0; i < 3; i++ {
+			to.AfterFuncThrottle(1, 1, func() { fired.Add(1) })
+		}
+		clock.Advance(time.Second)
+		want := int32(1)
+		switch name {
+		case "Stop":
+			to.Stop()
+		case "Reset":
+			to.Reset()
+		case "DrainAndStop":
+			to.DrainAndStop()
+			want = 3
+		}
+		clock.Advance(5 * time.Second)
+		if got := fired.Load(); got != want {
+			t.Fatalf("%s: expected %d callbacks to run, got %d", name, want, got)
+		}
+		select {
+		case <-to.Idle():
+		default:
+			t.Fatalf("%s: expected no callbacks left waiting", name)
+		}
+	}
+}
@@ -1,86 +1,2261 @@
 package gotimeout
 
 import (
+	"container/list"
+	"context"
+	"log/slog"
+	"math"
+	"math/rand"
+	"reflect"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type TimeoutCallback func()
 
+// Fallback is what a Timeout does with timeouts beyond the cached range of MaxSeconds
+// FallbackCoalesce is the default because long timeouts were already coalesced by whole seconds before Fallback existed,
+// FallbackUnique is opt-in so existing users keep sharing their timers
+type Fallback int
+
+const (
+	FallbackCoalesce Fallback = iota //coalesce them by whole seconds in a map of their own
+	FallbackUnique                   //give every one of them a unique timer, exact but not shared
+	FallbackClamp                    //cache them as the longest cached length, they fire early
+	FallbackError                    //reject them with ErrOutOfRange, to catch timeouts that are longer than intended
+)
+
+// RoundingMode is how a Timeout maps a duration to the bucket it shares, see Rounding
+type RoundingMode int
+
+const (
+	RoundNearest RoundingMode = iota //the nearest bucket, off by at most half a bucket either way, the smallest error on average
+	RoundFloor                       //the bucket at or below the duration, it fires up to a bucket early, never late
+	RoundCeil                        //the bucket at or above the duration, it is due no earlier than the duration
+)
+
+// ExecutionOrder is the order the callbacks of a fired entry run in
+type ExecutionOrder int
+
+const (
+	FIFO ExecutionOrder = iota //callbacks run in the order they were scheduled
+	LIFO                       //the callback scheduled last runs first, like defer
+)
+
+// ScheduleResult tells how AfterFuncResult, or AfterFuncHandle and Reset, scheduled a callback
+type ScheduleResult int
+
+const (
+	ScheduleRejected  ScheduleResult = iota //not scheduled, the error went to the ErrorHandler
+	ScheduleJoined                          //joined an existing entry, counted as a cache hit
+	ScheduleCreated                         //created the entry it went into, counted as a cache miss
+	ScheduleUnique                          //got a unique timer of its own, e.g. with FallbackUnique beyond MaxSeconds
+	ScheduleImmediate                       //a zero or negative timeout, the callback runs right away
+)
+
+// PanicPolicy is what a Timeout does when a callback panics, the panic is reported to the ErrorHandler first in any case
+type PanicPolicy int
+
+const (
+	PanicRecover   PanicPolicy = iota //recover and run the rest of the entry's callbacks
+	PanicStop                         //recover and skip the callbacks of the entry that did not start yet, nothing crashes
+	PanicPropagate                    //panic again on the goroutine the callback ran on, which crashes the process like time.AfterFunc would
+)
+
+// CancelFunc removes a scheduled callback before it fires
+// calling it after the callback has fired, or calling it more than once, is a no-op
+// cancelling and firing race for the callback, once a cancel won the callback never runs, not even if its entry fires at that instant
+// a cancel that lost is a no-op, the callback ran or is about to, Cancel of a CallbackRef tells which of the two happened
+type CancelFunc func()
+
+// PanicHandler is called with the recovered value whenever a callback panics, if set
+// with the default PanicPolicy a panicking callback never prevents the other callbacks sharing its entry from running
+var PanicHandler func(interface{})
+
+//invoke runs a single callback, isolating any panic from the callbacks around it unless PanicPolicy is PanicPropagate
+//it returns true if the callback panicked
+func (t *Timeout) invoke(callback TimeoutCallback) (panicked bool) {
+	return t.invokeTraced(0, callback)
+}
+
+//invokeTraced is invoke for a callback with the id of TraceCallbacks, a panic is logged with the id, 0 logs none
+func (t *Timeout) invokeTraced(id uint64, callback TimeoutCallback) (panicked bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if PanicHandler != nil {
+				PanicHandler(r)
+			}
+			t.reportTraced(&PanicError{Value: r}, id)
+			if t.PanicPolicy == PanicPropagate {
+				panic(r)
+			}
+			panicked = true
+		}
+	}()
+	callback()
+	return false
+}
+
+//stopsOn tells if the rest of a batch is skipped after a panic
+func (t *Timeout) stopsOn(panicked bool) bool {
+	return panicked && t.PanicPolicy == PanicStop
+}
+
+type callbackSlot struct {
+	callback      TimeoutCallback
+	entryCallback func(te *timeoutEntry) //used instead of callback by variants that need the entry they fired from
+	signal        chan<- struct{}        //used instead of callback by SignalAfter, saves allocating a closure per call
+	key           string                 //set by AfterFuncKeyed, empty otherwise
+	precise       bool                   //always use a unique entry, set by AfterFuncPrecise
+	notBefore     bool                   //never fire before the timeout, set by AfterFuncNotBefore
+	window        time.Duration          //overrides CacheWindow for AfterFuncWindow, 0 keeps it
+	index         int                    //position in timeoutEntry.callbacks, set once the callback is gathered from its shard
+	claimed       atomic.Bool            //taken by whoever runs or cancels the callback first, so exactly one of them wins
+	code          uintptr                //code pointer of callback with DedupCallbacks, 0 otherwise
+	priority      int                    //set by AfterFuncPriority, higher runs first, 0 otherwise
+	seq           uint64                 //order the callback joined an entry through a shard in
+	repeat        bool                   //scheduled by a repeating variant from its own callback, exempt from Strict
+	deadline      time.Time              //when the callback was meant to fire, set with OrderByDeadline
+	traceID       uint64                 //id logged with TraceCallbacks, 0 otherwise
+	throttle      throttleKey            //set by AfterFuncThrottle, a zero rate is not throttled
+	id            uint64                 //set by AfterFuncID, 0 otherwise
+}
+
+//fire runs the callback, te is the entry it fired from or nil if it ran without one
+func (s *callbackSlot) fire(te *timeoutEntry) {
+	if s.signal != nil {
+		select {
+		case s.signal <- struct{}{}:
+		default:
+		}
+		return
+	}
+	if s.entryCallback != nil {
+		s.entryCallback(te)
+		return
+	}
+	s.callback()
+}
+
 type timeoutEntry struct {
-	sync.Mutex
-	timestamp time.Time
-	callbacks []TimeoutCallback
-	completed bool
+	sync.RWMutex //held for reading by joinShard, for writing by everything else
+	timestamp    time.Time
+	deadline     time.Time                 //when the timer fires
+	callbacks    []*callbackSlot           //cancelled callbacks leave a nil behind, so indexes stay valid
+	live         int                       //callbacks that are not cancelled
+	keys         map[string]*callbackSlot  //keyed callbacks, created on first use
+	funcs        map[uintptr]*callbackSlot //callbacks by code pointer with DedupCallbacks, created on first use
+	completed    bool
+	stopped      bool
+	timer        Timer
+	bucket       int           //index in Timeout.entries, 0 if the entry is not in there
+	long         int64         //key in Timeout.long for timeouts beyond the cached range, 0 if the entry is not in there
+	longUse      *list.Element //position in Timeout.longLRU while the entry is in Timeout.long
+	unique       bool          //the entry belongs to a single callback and is not shared
+	notBefore    bool          //the entry is in Timeout.notBefore and fires a cache window late, never early
+	prioritized  bool          //a callback with a priority joined, so the callbacks are sorted before they run
+	timeout      time.Duration //timeout length the entry fires for
+	owner        *Timeout
+	shared       atomic.Pointer[BucketState] //created by the first AfterFuncShared callback to run
+	shards       atomic.Pointer[entryShards] //callbacks joining concurrently, created by the first joinShard
+	seq          atomic.Uint64               //last seq handed out by joinShard
 }
 
-//timeoutEntries expires after 500 milliseconds
-func (te *timeoutEntry) expired() bool {
-	return te.timestamp.Before(time.Now().Add(-time.Duration(500) * time.Millisecond))
+//timeoutEntries expires after the cache window, 500 milliseconds by default
+//an expired entry still fires, it just takes no new callbacks, so the window is both how long an entry coalesces
+//and how early a callback may fire: one joining an entry of age a fires a before its own deadline
+//both times come from Clock.Now, with the real clock they carry a monotonic reading so a wall clock step does not change the age
+//a clock without one that steps back gives a negative age, the entry is then taken as expired rather than joined for the length of the step
+//MaxEntryAge caps the age whatever the window, e.g. one widened by AfterFuncWindow
+func (te *timeoutEntry) expired(now time.Time, window time.Duration) bool {
+	age := now.Sub(te.timestamp)
+	if maxAge := te.owner.MaxEntryAge; maxAge > 0 && age > maxAge {
+		return true
+	}
+	return age > window || age < 0
 }
 
 func (te *timeoutEntry) AddCallback(callback TimeoutCallback) {
+	te.addSlot(&callbackSlot{callback: callback})
+}
+
+//addSlot adds the callback, if the entry already fired the late callback runs right away rather than being dropped
+func (te *timeoutEntry) addSlot(slot *callbackSlot) {
+	switch _, result := te.join(slot); result {
+	case joinAdded:
+		te.owner.stats.timersSaved.Add(1)
+		te.owner.Hooks.added(te.timeout, 1)
+	case joinCompleted:
+		//like every other callback it runs off the caller's goroutine, and never while holding the lock
+		te.owner.goInvoke(te, slot)
+	case joinStopped:
+		te.owner.report(ErrStopped)
+	case joinFull:
+		if _, _, err := te.owner.place(te.timeout, slot); err != nil {
+			te.owner.report(err)
+		}
+	}
+}
+
+type joinResult int
+
+const (
+	joinAdded     joinResult = iota
+	joinCompleted            //the entry already fired, the callback was not added
+	joinStopped              //the owning Timeout was stopped, the callback is dropped
+	joinFull                 //the entry holds MaxCallbacksPerBucket callbacks, the callback was not added
+)
+
+//join adds the callback unless the entry already fired or was stopped, and returns the slot that now holds it
+//for a key that is already in the entry this is the existing slot, with its callback replaced
+func (te *timeoutEntry) join(slot *callbackSlot) (*callbackSlot, joinResult) {
+	//completed is written by trigger under the lock, so it must be read under the lock too
+	//if the lock is taken, likely by other goroutines joining at the same time, the callback joins through a shard instead
+	if !te.TryLock() {
+		if te.shardable(slot) {
+			return slot, te.joinShard(slot)
+		}
+		te.Lock()
+	}
+	te.gather()
+	if te.stopped {
+		te.Unlock()
+		return nil, joinStopped
+	}
+	if te.completed {
+		te.Unlock()
+		return nil, joinCompleted
+	}
+	if slot.key != "" {
+		if existing, ok := te.keys[slot.key]; ok {
+			//newest wins, but keeps the position of the first registration
+			existing.callback = slot.callback
+			existing.entryCallback = slot.entryCallback
+			//and is traced by its own id from now on, the replaced callback is as good as cancelled
+			replaced := existing.traceID
+			existing.traceID = slot.traceID
+			te.Unlock()
+			if replaced != 0 {
+				te.owner.logCallback("gotimeout: callback cancelled", replaced, te.timeout)
+			}
+			return existing, joinAdded
+		}
+	} else if slot.code != 0 {
+		if existing, ok := te.funcs[slot.code]; ok {
+			//the same function was added before, it only runs once
+			te.Unlock()
+			return existing, joinAdded
+		}
+	}
+	//checked before indexing the slot, a rejected slot must not be found by a later key or function it is not in the entry for
+	if te.full(1) {
+		te.Unlock()
+		return nil, joinFull
+	}
+	if slot.key != "" {
+		if te.keys == nil {
+			te.keys = make(map[string]*callbackSlot)
+		}
+		te.keys[slot.key] = slot
+	} else if slot.code != 0 {
+		if te.funcs == nil {
+			te.funcs = make(map[uintptr]*callbackSlot)
+		}
+		te.funcs[slot.code] = slot
+	}
+	if te.callbacks == nil {
+		te.callbacks = getCallbacks()
+	}
+	slot.index = len(te.callbacks)
+	te.callbacks = append(te.callbacks, slot)
+	te.prioritized = te.prioritized || slot.priority != 0
+	te.live++
+	te.owner.stats.pendingCallbacks.Add(1)
+	te.Unlock()
+	return slot, joinAdded
+}
+
+//full tells if adding n callbacks would take a shared entry beyond MaxCallbacksPerBucket, the lock must be held
+//an empty entry takes any number, so a batch larger than the limit still gets an entry of its own
+func (te *timeoutEntry) full(n int) bool {
+	limit := te.owner.MaxCallbacksPerBucket
+	return limit > 0 && !te.unique && te.live > 0 && te.live+n > limit
+}
+
+//reserve grows the callbacks slice to hold at least n callbacks, false if the entry can no longer take callbacks
+func (te *timeoutEntry) reserve(n int) bool {
+	te.Lock()
+	defer te.Unlock()
+	if te.completed {
+		return false
+	}
+	te.gather()
+	if cap(te.callbacks) < n {
+		grown := make([]*callbackSlot, len(te.callbacks), n)
+		copy(grown, te.callbacks)
+		te.callbacks = grown
+	}
+	return true
+}
+
+//joinAll adds all callbacks under a single lock acquisition, either all of them are added or none
+//with DedupCallbacks a function already in the entry, or earlier in the batch, is skipped like join skips it
+//the slots that joined are moved to the front of slots, it returns how many there are
+func (te *timeoutEntry) joinAll(slots []*callbackSlot) (int, joinResult) {
+	te.Lock()
+	defer te.Unlock()
+	if te.stopped {
+		return 0, joinStopped
+	}
 	if te.completed {
-		return //TODO error? this should not happen so...
+		return 0, joinCompleted
+	}
+	te.gather()
+	if te.full(len(slots)) {
+		return 0, joinFull
+	}
+	if te.callbacks == nil {
+		te.callbacks = getCallbacks()
 	}
+	added := 0
+	for _, slot := range slots {
+		if slot.code != 0 {
+			if _, ok := te.funcs[slot.code]; ok {
+				continue
+			}
+			if te.funcs == nil {
+				te.funcs = make(map[uintptr]*callbackSlot)
+			}
+			te.funcs[slot.code] = slot
+		}
+		slot.index = len(te.callbacks)
+		te.callbacks = append(te.callbacks, slot)
+		slots[added] = slot
+		added++
+	}
+	te.live += added
+	te.owner.stats.pendingCallbacks.Add(int64(added))
+	return added, joinAdded
+}
+
+// removeSlot removes a single callback, leaving the other callbacks sharing the entry untouched
+//it returns false if the entry already fired or the callback was cancelled before
+func (te *timeoutEntry) removeSlot(slot *callbackSlot) bool {
+	removed, emptied := te.takeSlot(slot)
+	if !removed {
+		return false
+	}
+	if emptied {
+		t := te.owner
+		t.disarm(te)
+		t.release(te)
+		t.emptied(te)
+	}
+	te.owner.Hooks.cancelWon(te.timeout)
+	if slot.traceID != 0 {
+		te.owner.logCallback("gotimeout: callback cancelled", slot.traceID, te.timeout)
+	}
+	return true
+}
 
+//takeSlot is removeSlot under the entry lock, emptied tells if it took the last callback and discarded the entry for OnEmpty
+func (te *timeoutEntry) takeSlot(slot *callbackSlot) (removed, emptied bool) {
 	te.Lock()
 	defer te.Unlock()
-	te.callbacks = append(te.callbacks, callback)
+	if te.completed {
+		//already fired, nothing to remove
+		return false, false
+	}
+	//a callback that joined through a shard only gets its index once gathered
+	te.gather()
+	index := slot.index
+	if index < 0 || index >= len(te.callbacks) || te.callbacks[index] != slot {
+		return false, false
+	}
+	if !slot.claimed.CompareAndSwap(false, true) {
+		return false, false
+	}
+	if slot.key != "" && te.keys[slot.key] == slot {
+		delete(te.keys, slot.key)
+	}
+	if slot.code != 0 && te.funcs[slot.code] == slot {
+		delete(te.funcs, slot.code)
+	}
+	//leave a hole rather than compacting, so the other callbacks keep their index
+	te.callbacks[index] = nil
+	te.live--
+	te.owner.stats.pendingCallbacks.Add(-1)
+	if te.live == 0 && te.owner.OnEmpty != nil && !te.unique {
+		//discarded under the same lock, so a callback joining right after finds it completed and goes to a fresh entry
+		te.discardLocked(false)
+		return true, true
+	}
+	return true, false
+}
+
+//emptied tells OnEmpty about an entry that cancellation emptied before it fired, unique entries are not shared and left out
+func (t *Timeout) emptied(te *timeoutEntry) {
+	if onEmpty := t.OnEmpty; onEmpty != nil && !te.unique {
+		t.invoke(func() { onEmpty(te.timeout) })
+	}
 }
 
 func (te *timeoutEntry) trigger() {
+	//mark the entry completed and take the callbacks under the lock, then run them without holding it
+	//callbacks added from now on see completed and run on their own, so they can safely schedule new timeouts
+	te.Lock()
+	if te.completed {
+		//stopped before the timer got here
+		te.Unlock()
+		return
+	}
+	te.completed = true
+	te.gather()
+	te.releaseShards()
+	//the slice is handed over rather than copied, the entry forgets it before unlocking, so no append can reach it
+	//an append after this sees completed and runs the late callback on its own, one before it is in the slice, never both
+	callbacks := te.callbacks
+	te.callbacks = nil
+	te.keys = nil
+	te.funcs = nil
+	te.Unlock()
+	te.owner.Hooks.triggered(te.timeout)
+	te.fireCallbacks(callbacks)
+}
+
+//fireCallbacks tells OnTrigger, Events, the Logger and the Tracer about the entry and runs its callbacks
+//the callbacks were taken out of the entry by trigger, or by drain for DrainAndStop, a drained entry fires like any other
+func (te *timeoutEntry) fireCallbacks(callbacks []*callbackSlot) {
+	all := callbacks
+	callbacks = compact(callbacks)
+
+	if onTrigger := te.owner.OnTrigger; onTrigger != nil {
+		te.owner.invoke(func() { onTrigger(te.timeout, len(callbacks)) })
+	}
+	var ids []uint64
+	if te.owner.tracing() {
+		ids = traceIDs(callbacks)
+	}
+	te.owner.emit(te.timeout, len(callbacks), ids)
+	if te.owner.Logger != nil {
+		te.owner.logFire(te.timeout, len(callbacks), ids)
+	}
+
+	done := func() {
+		putCallbacks(all)
+		te.owner.pending.done()
+	}
+	if tracer := te.owner.Tracer; tracer != nil {
+		end := tracer.Fire(te.timeout, len(callbacks))
+		untraced := done
+		done = func() {
+			end()
+			untraced()
+		}
+	}
+	te.owner.run(te, callbacks, done)
+}
+
+//timeouts are cached in buckets of 100 milliseconds by default rather than whole seconds
+//whole seconds are multiples of 100ms, so AfterFunc shares exactly the buckets it would with 1s,
+//while AfterDuration gets sub-second buckets instead of being rounded by up to half a second
+const defaultGranularity = 100 * time.Millisecond
+
+const defaultCacheWindow = 500 * time.Millisecond
+
+//we support 10 minutes timeouts in the entries array by default, longer ones go to a map
+const defaultMaxSeconds = 60 * 10
+
+//stop cancels the timer and drops all pending callbacks
+func (te *timeoutEntry) stop() {
+	te.discard(true)
+}
+
+//discard is stop without marking the entry stopped, callbacks racing into it go to a fresh entry instead of failing
+//it returns the number of callbacks dropped, 0 if the entry already fired or was discarded before
+func (te *timeoutEntry) discard(stopped bool) int {
+	te.Lock()
+	live := te.live
+	callbacks, ok := te.discardLocked(stopped)
+	te.Unlock()
+	if !ok {
+		return 0
+	}
+	msg := "gotimeout: callback cancelled"
+	if stopped {
+		msg = "gotimeout: callback dropped"
+	}
+	te.owner.dropped(msg, te.timeout, callbacks...)
+	return live
+}
+
+//discardLocked is discard with the entry lock held, it returns the callbacks it dropped, holes of cancelled ones included
+//false means the entry already fired or was discarded before
+func (te *timeoutEntry) discardLocked(stopped bool) ([]*callbackSlot, bool) {
+	if te.completed {
+		return nil, false
+	}
+	te.timer.Stop()
+	te.completed = true
+	te.stopped = stopped
+	te.gather()
+	te.releaseShards()
+	callbacks := te.callbacks
+	te.callbacks = nil
+	te.keys = nil
+	te.funcs = nil
+	te.owner.stats.pendingCallbacks.Add(-int64(te.live))
+	te.owner.pending.done()
+	return callbacks, true
+}
+
+//dropped is told about callbacks that were removed without running, it forgets their AfterFuncID ids so Status no longer reports them waiting
+//with TraceCallbacks it logs msg for each of them, with the timeout length of their entry
+func (t *Timeout) dropped(msg string, d time.Duration, callbacks ...*callbackSlot) {
+	locked := false
+	for _, slot := range callbacks {
+		if slot == nil {
+			continue
+		}
+		if slot.traceID != 0 {
+			t.logCallback(msg, slot.traceID, d)
+		}
+		if slot.id == 0 {
+			continue
+		}
+		if !locked {
+			t.idsMu.Lock()
+			locked = true
+		}
+		delete(t.ids, slot.id)
+	}
+	if locked {
+		t.idsMu.Unlock()
+	}
+}
+
+//drain cancels the timer like stop, but hands back the pending callbacks so fireCallbacks can run them right away
+//false means the entry already fired or was stopped, whoever completes the entry owns its callbacks
+func (te *timeoutEntry) drain() ([]*callbackSlot, bool) {
+	return te.detach(true)
+}
+
+//detach is drain, marking the entry stopped only if stopped is set, like discard
+func (te *timeoutEntry) detach(stopped bool) ([]*callbackSlot, bool) {
 	te.Lock()
 	defer te.Unlock()
+	if te.completed {
+		return nil, false
+	}
+	te.timer.Stop()
 	te.completed = true
-	for _, callback := range te.callbacks {
-		callback()
+	te.stopped = stopped
+	te.gather()
+	te.releaseShards()
+	callbacks := te.callbacks
+	te.callbacks = nil
+	te.keys = nil
+	te.funcs = nil
+	return callbacks, true
+}
+
+//compact drops the holes left by cancelled callbacks in place, the slice must no longer be shared with the entry
+func compact(callbacks []*callbackSlot) []*callbackSlot {
+	live := callbacks[:0]
+	for _, slot := range callbacks {
+		if slot != nil {
+			live = append(live, slot)
+		}
 	}
+	return live
 }
 
 type Timeout struct {
-	entries [60 * 10]*timeoutEntry //we support 10 minutes timeouts with caching, else unique instance
+	mu      sync.Mutex
+	armed   map[*timeoutEntry]struct{} //every entry with a running timer, including overwritten and unique ones
+	stopped atomic.Bool
+	stats   stats
+	pending pending
+
+	entriesOnce sync.Once
+	entries     atomic.Pointer[entrySlots] //slots are read and written without locking, atomics keep that well defined
+	resizeMu    sync.Mutex                 //serializes SetMaxSeconds
+
+	notBeforeOnce sync.Once
+	notBefore     atomic.Pointer[entrySlots] //entries of AfterFuncNotBefore, created on first use
+
+	// MaxSeconds is the longest timeout that is cached in the entries array, zero means 600 seconds
+	// longer timeouts are coalesced by whole seconds in a map instead, unless Fallback says otherwise
+	// it must be set before the Timeout is first used, SetMaxSeconds changes the cached range later on
+	MaxSeconds int
+
+	// CacheWindow controls how long an entry is reused before a new one is created, zero means 500ms
+	// a smaller window is more accurate, AfterFunc(10) fires between 10s-CacheWindow and 10s, but creates more timers
+	// coalescing and accuracy are the same knob: an entry fires at its own deadline, so a callback joining it
+	// CacheWindow after it was created fires CacheWindow early, at most one entry per length and window is created
+	// AfterDuration adds up to half a Granularity of rounding on top, in either direction
+	CacheWindow time.Duration
+
+	// MaxEntryAge is the oldest an entry may be to still be joined, whatever the window, zero means no cap
+	// it bounds how long callbacks keep piling onto the one timer of an entry, e.g. one with a wide window, so a timer that wedges
+	// only takes the callbacks of MaxEntryAge with it, later ones get a fresh entry with a timer of its own
+	MaxEntryAge time.Duration
+
+	// Clock is the source of time, nil means the real time package
+	Clock Clock
+
+	// TimerFunc arms every timer of the Timeout and returns a func that cancels it, used to plug in a virtual time scheduler
+	// it takes precedence over the timing wheel, the fine wheel and Clock.AfterFunc, nil means Clock.AfterFunc, which is time.AfterFunc by default
+	// with a TimerFunc, timeouts within the horizon of the fine wheel go to the cached buckets like longer ones
+	TimerFunc func(d time.Duration, f func()) (stop func())
+
+	// Granularity is the size of a cache bucket, durations are rounded to the nearest multiple of it, zero means 100ms
+	// the default is finer than a second so AfterDuration stays accurate, AfterFunc's whole seconds share buckets just as with 1s
+	// a coarse granularity shares more timers but is less accurate, it must be set before the Timeout is first used
+	Granularity time.Duration
+
+	// Jitter offsets the fire time of every new entry by a random amount within [-Jitter, +Jitter]
+	// this spreads out entries that would otherwise fire at the same instant, at the cost of accuracy
+	// with jitter AfterFunc(10) fires between 10s-CacheWindow-Jitter and 10s+Jitter
+	Jitter time.Duration
+
+	// OnTrigger is called once per entry when it fires, with the entry's timeout length and the number of callbacks it batched
+	// it runs outside of the entry lock, before the callbacks
+	// the timeout is a time.Duration rather than whole seconds so the sub-second buckets of Granularity and AfterDuration stay distinguishable
+	OnTrigger func(timeout time.Duration, count int)
+
+	// OnCreate is called whenever a lookup creates an entry for a timeout length, as there was none to join
+	// it runs outside of the entry lock on the scheduling path, so it should be cheap, see CacheMisses for a count
+	OnCreate func(timeout time.Duration)
+
+	// OnEmpty is called when cancelling the last callback of an entry emptied it before it fired, with the entry's timeout length
+	// whether by a CancelFunc, Cancel, a CancelToken, CancelAll or Extract, an entry that had no callbacks to cancel is not emptied by them
+	// e.g. to release resources held for the batch right away instead of when the entry would have fired, it is never called for fired entries
+	// with OnEmpty set an emptied entry stops its timer and is dropped, callbacks scheduled after that get a fresh entry, which may empty again
+	// it runs outside of the entry lock on the goroutine that cancelled, unique timers are not entries shared by a length and are left out
+	OnEmpty func(timeout time.Duration)
+
+	// OnSchedule is called by AfterFuncPersistent with the id of the callback and when it is due, e.g. to journal it for Restore
+	// OnFire is called with the id once the callback ran, so the journal can forget it, both run on the goroutine of the event
+	OnSchedule func(id uint64, fireAt time.Time)
+	OnFire     func(id uint64)
+	persistSeq atomic.Uint64 //last id handed out by AfterFuncPersistent or seen by Restore
+
+	// ErrorHandler is called with problems that would otherwise go unnoticed, nil keeps them silent
+	// e.g. ErrStopped for a callback dropped after Stop, or a *PanicError for a callback that panicked
+	// TryAfterFunc returns its error instead of reporting it
+	ErrorHandler func(error)
+
+	// MaxPendingCallbacks limits the number of callbacks waiting in entries, zero means no limit
+	// beyond it TryAfterFunc returns ErrTooManyPending, the other variants drop the callback and report the error
+	// the limit is soft, callbacks scheduled concurrently may overshoot it by the number of goroutines scheduling at once
+	MaxPendingCallbacks int
+
+	// Workers is the number of goroutines callbacks of a fired entry are dispatched to, zero runs them on the timer goroutine
+	// which is a fresh goroutine per entry with the real clock and the timing wheels, see Clock
+	// with workers a slow callback no longer delays the rest of its entry, but callbacks of an entry are only
+	// started in FIFO order, they can run concurrently and finish in any order
+	Workers int
+
+	// CallbackTimeout abandons a callback that runs longer than this, zero waits for every callback however long it takes
+	// each callback then runs on its own goroutine, one that overruns is reported as ErrCallbackTimeout and the entry moves on
+	// the callback is not killed, it keeps running in the background and Wait no longer waits for it
+	CallbackTimeout time.Duration
+
+	// DedupCallbacks skips a callback if the same function is already waiting in its entry, off by default
+	// functions are compared by their code pointer using reflect, which costs a lookup per callback
+	// closures created by the same function literal have the same code pointer, they count as duplicates whatever they captured
+	// a callback skipped as a duplicate shares the CancelFunc of the first one, cancelling either cancels both
+	DedupCallbacks bool
+
+	// ExecutionOrder is the order the callbacks of an entry run in, FIFO by default
+	// with Workers the callbacks are started in this order, but may still finish in any order
+	ExecutionOrder ExecutionOrder
+
+	// OrderByDeadline runs the callbacks of an entry in the order they were meant to fire, earliest first
+	// every callback remembers when it was due, the time it was scheduled plus its own timeout, e.g. 250ms for AfterDuration
+	// callbacks coalesced into one entry thus run in deadline order rather than the order they joined in, ties keep ExecutionOrder
+	// the entry still fires at its own instant, only the order changes, and a higher AfterFuncPriority still runs first
+	OrderByDeadline bool
+
+	// MeasureCallbacks times every callback and adds it to Stats, see Measured, CallbackTotal and CallbackMax, off by default
+	// it costs reading the clock twice per callback, the wall clock is used even with WithClock, as it measures the callback rather than timeouts
+	// a callback abandoned after CallbackTimeout is still counted once it returns
+	MeasureCallbacks bool
+
+	// Hooks lets tests observe the points the ordering, cancellation and exactly once guarantees hinge on, nil by default
+	Hooks *TestingHooks
+
+	// Fallback decides what happens to timeouts longer than MaxSeconds, FallbackCoalesce by default to keep the earlier behaviour
+	Fallback Fallback
+
+	// Rounding decides which bucket a duration like the 250ms of AfterDuration shares, RoundNearest by default
+	// with Granularity 100ms RoundNearest and RoundCeil put 250ms in the 300ms bucket and RoundFloor in the 200ms one
+	// a duration shorter than the smallest bucket it rounds to, e.g. 80ms with RoundFloor, gets a unique timer
+	// the entry of a bucket still fires up to CacheWindow early for callbacks that join it late, even with RoundCeil,
+	// use AfterFuncNotBefore for a timeout that must never fire early, beyond MaxSeconds whole seconds are rounded the same way
+	Rounding RoundingMode
+
+	// PanicPolicy decides what happens when a callback panics, PanicRecover by default
+	// with PanicStop the skipped callbacks count as neither fired nor pending, with Workers those already started still finish
+	PanicPolicy PanicPolicy
+
+	// Strict rejects scheduling on the Timeout from within one of its own callbacks with ErrReentrant, off by default
+	// TryAfterFunc returns the error and the other variants report it, an ErrorHandler that panics flags re-entrant scheduling in tests
+	// it is detected per goroutine, a callback that schedules from a goroutine it started is not caught
+	// the repeating variants such as EveryFunc and Backoff still schedule their next run, only the callback's own calls are rejected
+	Strict bool
+	firing firing
+
+	// Coalesce lets a callback join an entry of a nearby length, up to this many seconds shorter or longer, zero means off
+	// it only looks for one if the callback's own length has no entry to join, and takes the nearest, the longer one on a tie
+	// this trades accuracy for fewer timers when lengths are spread but tolerant: the callback fires when the joined entry does,
+	// up to Coalesce seconds early or late on top of CacheWindow, it applies to the cached range, not beyond MaxSeconds
+	Coalesce int
+
+	// MaxCallbacksPerBucket bounds the number of callbacks an entry batches, zero means no bound
+	// a callback that finds its entry full spills to a fresh entry that takes over the length, the full one keeps its callbacks
+	// and fires on time, so no single batch grows without bound, entries of the fine wheel spill to unique timers instead
+	// callbacks that spilled are counted by Stats.Overflows
+	MaxCallbacksPerBucket int
+
+	// MaxLongEntries bounds the number of lengths beyond MaxSeconds that FallbackCoalesce keeps entries for, zero means no bound
+	// once it is reached the least recently joined length is evicted, callbacks for it start a fresh entry
+	// an evicted entry keeps its timer and fires its callbacks on time, it only stops taking new ones, so nothing is dropped
+	MaxLongEntries int
+
+	// Logger logs panics, dropped callbacks, fired entries and Stop, and every callback with TraceCallbacks, nil logs nothing
+	// errors are logged in addition to being passed to the ErrorHandler, fired entries only at debug level
+	Logger *slog.Logger
+
+	// TraceCallbacks gives every callback an id and logs it at debug level when it is scheduled, fires and is cancelled, dropped or extracted
+	// a panic or abandoned callback is logged with its id too, TimeoutEvent lists the ids of the callbacks it ran, a keyed replace logs the
+	// replaced callback as cancelled and the new one as scheduled
+	// e.g. to follow a single callback through the logs of an incident, the ids count up from 1 per Timeout
+	// it needs a Logger, and costs an atomic increment and a few log calls per callback, off by default
+	TraceCallbacks bool
+	traceSeq       atomic.Uint64 //last id handed out with TraceCallbacks
+
+	// SerialDispatch runs the callbacks of all entries on a single goroutine, entries that are due together run by deadline
+	// this gives a total order across entries, e.g. for deterministic tests, at the cost of throughput
+	// a slow callback holds up every entry queued behind it, also those of other lengths, Workers is ignored
+	SerialDispatch bool
+
+	// FairDispatch is SerialDispatch taking turns between the entries that are ready, one callback of each at a time
+	// e.g. after a wake from sleep, when many entries are overdue at once, a huge entry thus no longer holds up the small ones queued behind it
+	// entries join the turns in the order they fired, rather than by deadline, the callbacks of each one still run in their own order
+	// it implies SerialDispatch, a slow callback still holds up the others, Workers is ignored
+	FairDispatch bool
+
+	// Tracer is told about every entry that fires, e.g. to wrap its callbacks in a tracing span, nil traces nothing
+	Tracer Tracer
+
+	ctxOnce   sync.Once
+	ctx       context.Context //handed to AfterFuncCtx callbacks, cancelled on Stop
+	ctxCancel context.CancelFunc
+
+	longMu  sync.Mutex
+	long    map[int64]*timeoutEntry //entries for timeouts beyond MaxSeconds, by whole seconds
+	longLRU list.List               //entries in long, the most recently joined first
+
+	eventsOnce sync.Once
+	events     atomic.Pointer[chan TimeoutEvent] //nil until Events is called, so nothing is built for nobody
+
+	idsMu   sync.Mutex
+	lastID  uint64              //last id handed out by AfterFuncID, ids are never reused
+	resetID uint64              //ids up to this one were handed out before the last Reset
+	ids     map[uint64]struct{} //ids of AfterFuncID whose callback has not run yet
+
+	pauseMu sync.Mutex
+	paused  bool
+	held    []*timeoutEntry //entries that came due while paused, in the order they did
+
+	resetMu sync.Mutex
+	resets  map[string]*resetHandle //last callback scheduled per key by AfterFuncReset
+
+	onceMu sync.Mutex
+	onces  map[string]*onceState //keys of AfterFuncOnce with copies that did not fire yet
+
+	throttleMu sync.Mutex
+	throttles  map[throttleKey]*throttle //lengths and rates of AfterFuncThrottle within a burst, forgotten once it drained
+
+	wheel      *wheel     //arms every entry when set, see WithTimingWheel
+	fine       *fineWheel //coalesces short timeouts by fire time when set, see WithFineWheel
+	poolOnce   sync.Once
+	poolMu     sync.Mutex                 //serializes SetWorkerCount
+	pool       atomic.Pointer[workerPool] //nil runs callbacks on the timer goroutine
+	dispatcher dispatcher                 //runs fired entries one at a time with SerialDispatch
+}
+
+// NewTimeout creates an independent Timeout with its own cache
+// use this instead of the package level functions to avoid sharing timer state with the rest of the process
+// it returns an error wrapping ErrInvalidConfig, and no Timeout, if the options do not make sense, see Validate
+func NewTimeout(opts ...Option) (*Timeout, error) {
+	t := &Timeout{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if err := t.Validate(); err != nil {
+		return nil, err
+	}
+	t.getEntries()
+	return t, nil
+}
+
+// MustNewTimeout works like NewTimeout, but panics if the options do not make sense
+func MustNewTimeout(opts ...Option) *Timeout {
+	t, err := NewTimeout(opts...)
+	if err != nil {
+		panic(err)
+	}
+	return t
 }
 
-var timeout = &Timeout{}
+//default instance used by the package level functions
 
 func AfterFunc(seconds int, callback TimeoutCallback) {
-	timeout.AfterFunc(seconds, callback)
+	Default().AfterFunc(seconds, callback)
+}
+
+func AfterDuration(d time.Duration, callback TimeoutCallback) {
+	Default().AfterDuration(d, callback)
+}
+
+func AfterFuncResult(seconds int, callback TimeoutCallback) ScheduleResult {
+	return defaultTimeout().AfterFuncResult(seconds, callback)
+}
+
+func AfterFuncCancellable(seconds int, callback TimeoutCallback) CancelFunc {
+	return Default().AfterFuncCancellable(seconds, callback)
+}
+
+func AfterFuncContext(ctx context.Context, seconds int, callback TimeoutCallback) {
+	Default().AfterFuncContext(ctx, seconds, callback)
+}
+
+func TryAfterFunc(seconds int, callback TimeoutCallback) error {
+	return Default().TryAfterFunc(seconds, callback)
+}
+
+func AfterFuncKeyed(seconds int, key string, callback TimeoutCallback) {
+	defaultTimeout().AfterFuncKeyed(seconds, key, callback)
+}
+
+func AfterFuncBatch(seconds int, callbacks []TimeoutCallback) {
+	defaultTimeout().AfterFuncBatch(seconds, callbacks)
+}
+
+func AfterFuncAt(seconds int, callback func(scheduled, actual time.Time)) {
+	defaultTimeout().AfterFuncAt(seconds, callback)
+}
+
+func AtFunc(deadline time.Time, callback TimeoutCallback) {
+	defaultTimeout().AtFunc(deadline, callback)
+}
+
+func AtNextBoundary(d time.Duration, callback TimeoutCallback) {
+	defaultTimeout().AtNextBoundary(d, callback)
+}
+
+func AfterFuncPrecise(d time.Duration, callback TimeoutCallback) CancelFunc {
+	return defaultTimeout().AfterFuncPrecise(d, callback)
+}
+
+func AfterFuncNotBefore(d time.Duration, callback TimeoutCallback) CancelFunc {
+	return defaultTimeout().AfterFuncNotBefore(d, callback)
+}
+
+func AfterFuncWindow(d, window time.Duration, callback TimeoutCallback) CancelFunc {
+	return defaultTimeout().AfterFuncWindow(d, window, callback)
+}
+
+func AfterFuncRef(seconds int, callback TimeoutCallback) CallbackRef {
+	return defaultTimeout().AfterFuncRef(seconds, callback)
+}
+
+func Cancel(ref CallbackRef) bool {
+	return defaultTimeout().Cancel(ref)
+}
+
+func SignalAfter(seconds int, ch chan<- struct{}) {
+	defaultTimeout().SignalAfter(seconds, ch)
+}
+
+func AfterFuncN(seconds int, callback func(seconds int)) {
+	defaultTimeout().AfterFuncN(seconds, callback)
+}
+
+func AfterFuncCtx(seconds int, callback func(context.Context)) {
+	Default().AfterFuncCtx(seconds, callback)
+}
+
+func Reserve(seconds int, n int) {
+	defaultTimeout().Reserve(seconds, n)
+}
+
+func Prewarm(seconds ...int) {
+	defaultTimeout().Prewarm(seconds...)
+}
+
+func CancelAll(seconds int) int {
+	return defaultTimeout().CancelAll(seconds)
+}
+
+func After(seconds int) <-chan time.Time {
+	return defaultTimeout().After(seconds)
 }
 
 // AfterFunc works similar to time.AfterFunc, with the difference that timers are cached based on the timeout length
 // therefore Seconds are used as a "granular enough" unit for caching
-// any timeout entry older than 500ms will be recreated and overwritten
+// any timeout entry older than the cache window (500ms by default) will be recreated and overwritten
 // TLDR; the purpose of all this is to avoid spawning thousands of timers under heavy load
 // the standard usecase would be to use a timeout for some form of request, where the timeout is a few seconds
 // due to the 500ms expiration, if a timeout is setup using AfterFunc(10), this in reality means 9.5-10 seconds before timeout
+// callbacks sharing an entry are guaranteed to run in the order they were scheduled, unless Workers or LIFO ExecutionOrder is set
+// a zero or negative timeout runs the callback right away, on its own goroutine
+// every callback runs exactly once, also when many goroutines schedule the same length at once, unless Stop drops it
 func (t *Timeout) AfterFunc(seconds int, callback TimeoutCallback) {
-	//no timeout, just invoke it
-	if seconds == 0 {
-		callback()
-		return
+	t.scheduleOrReport(secondsToDuration(seconds), t.userSlot(callback))
+}
+
+// AfterFuncResult works like AfterFunc, but tells whether the callback joined an existing entry, created one, or got a unique timer
+// it is meant for tuning CacheWindow and Granularity, AfterFunc itself does not track this
+func (t *Timeout) AfterFuncResult(seconds int, callback TimeoutCallback) ScheduleResult {
+	_, _, result, err := t.placeResult(secondsToDuration(seconds), t.userSlot(callback))
+	if err != nil {
+		t.report(err)
 	}
+	return result
+}
 
-	if seconds > len(t.entries)-1 {
-		//just use a unique instance
-		timeout := time.Duration(seconds) * time.Second
-		time.AfterFunc(timeout, callback)
-		return
+// AfterDuration works like AfterFunc, but accepts a time.Duration
+// durations are rounded to a bucket of Granularity for caching, e.g. 250ms ends up in the 300ms bucket by default, see Rounding
+// durations that round to no bucket, shorter than half a bucket by default, get a unique timer, as there is no bucket to share
+// with WithFineWheel, durations up to its horizon go to the fine wheel instead
+func (t *Timeout) AfterDuration(d time.Duration, callback TimeoutCallback) {
+	t.scheduleOrReport(d, t.userSlot(callback))
+}
+
+// AfterFuncCancellable works like AfterFunc, but returns a CancelFunc that removes the callback from its entry
+// e.g. a request that completes well before its timeout can cancel the callback instead of leaving it in the shared entry
+// cancelling only affects this callback, other callbacks sharing the same entry still fire
+// a zero timeout can be cancelled too, as long as the goroutine running it did not get to it yet
+func (t *Timeout) AfterFuncCancellable(seconds int, callback TimeoutCallback) CancelFunc {
+	return t.scheduleOrReport(secondsToDuration(seconds), t.userSlot(callback))
+}
+
+// TryAfterFunc works like AfterFunc, but returns an error if the callback could not be scheduled
+// e.g. ErrStopped after Stop, where AfterFunc silently drops the callback
+// a negative timeout is rejected with ErrNegativeTimeout instead of running the callback immediately
+func (t *Timeout) TryAfterFunc(seconds int, callback TimeoutCallback) error {
+	if seconds < 0 {
+		return ErrNegativeTimeout
 	}
+	_, err := t.scheduleSlot(secondsToDuration(seconds), t.userSlot(callback))
+	return err
+}
+
+// AfterFuncKeyed works like AfterFunc, but an entry holds at most one callback per key
+// scheduling a key that is already in the active entry replaces the callback, the newest callback wins
+// the callback keeps the position of the first registration, so it still runs in FIFO order relative to the others
+// once the entry fired or expired, the key starts over in the next entry
+func (t *Timeout) AfterFuncKeyed(seconds int, key string, callback TimeoutCallback) {
+	t.scheduleOrReport(secondsToDuration(seconds), &callbackSlot{callback: callback, key: key})
+}
 
-	//fetch entry from entry array
-	entry := t.entries[seconds]
+// AfterFuncBatch schedules all callbacks for the same timeout length at once
+// the entry is looked up or created once and the whole batch is added under a single lock acquisition
+// so the callbacks always end up in the same entry, in slice order
+func (t *Timeout) AfterFuncBatch(seconds int, callbacks []TimeoutCallback) {
+	t.scheduleBatch(secondsToDuration(seconds), callbacks)
+}
 
-	//if entry doesn't exist, or if entry has expired, recreate it
-	if entry == nil || entry.expired() {
-		entry = &timeoutEntry{
-			timestamp: time.Now(),
+// AfterFuncAt works like AfterFunc, but the callback receives when it was scheduled to fire and when it actually fired
+// scheduled is the deadline of the entry the callback joined, so actual-scheduled measures the delay of the timer
+// and AfterFunc(10)-scheduled shows the skew introduced by the cache window
+func (t *Timeout) AfterFuncAt(seconds int, callback func(scheduled, actual time.Time)) {
+	start := t.now()
+	t.scheduleOrReport(secondsToDuration(seconds), &callbackSlot{entryCallback: func(te *timeoutEntry) {
+		scheduled := start
+		if te != nil {
+			scheduled = te.deadline
 		}
-		//this is racy and we don't care, it's OK if it's overwritten, wasting an entry is cheaper than locking
-		t.entries[seconds] = entry
-		timeout := time.Duration(seconds) * time.Second
-		time.AfterFunc(timeout, entry.trigger)
+		callback(scheduled, t.now())
+	}})
+}
+
+// AfterFuncN works like AfterFunc, but the callback receives the timeout length it was scheduled with
+// so a single function can serve several lengths, seconds is taken when scheduling and never read back from the entry
+func (t *Timeout) AfterFuncN(seconds int, callback func(seconds int)) {
+	t.scheduleOrReport(secondsToDuration(seconds), &callbackSlot{callback: func() { callback(seconds) }})
+}
+
+// AtFunc schedules the callback for an absolute deadline, the remaining time is bucketed like AfterDuration
+// if the deadline already passed, the callback runs right away, on its own goroutine
+func (t *Timeout) AtFunc(deadline time.Time, callback TimeoutCallback) {
+	t.schedule(deadline.Sub(t.now()), callback)
+}
+
+// AtNextBoundary schedules the callback for the next multiple of d on the wall clock, e.g. the top of the next minute for time.Minute
+// boundaries are counted in the location of the Clock's time, so time.Hour or 24*time.Hour align to local hours and midnights
+// across a DST change it is the first time the wall clock shows such a multiple, a skipped hour has none and a repeated hour has two
+// it is wall clock based, not monotonic: the remaining time is computed once, a clock step after scheduling does not move the deadline
+// nodes whose clocks agree fire together, which is the point, e.g. to align periodic flushes, a d of zero or less runs the callback right away
+func (t *Timeout) AtNextBoundary(d time.Duration, callback TimeoutCallback) {
+	now := t.now()
+	if d <= 0 {
+		t.AtFunc(now, callback)
+		return
 	}
+	t.AtFunc(nextBoundary(now, d), callback)
+}
 
-	entry.AddCallback(callback)
+//nextBoundary returns the first instant after now at which the wall clock of the location of now shows a multiple of d
+func nextBoundary(now time.Time, d time.Duration) time.Time {
+	_, offset := now.Zone()
+	boundary := boundaryIn(now, offset, d)
+	if _, at := boundary.Zone(); at != offset {
+		//the offset changes before the boundary, counted in the new offset there may be an earlier one, or the only one on the wall clock
+		other := boundaryIn(now, at, d)
+		if other.After(now) && onBoundary(other, d) && (other.Before(boundary) || !onBoundary(boundary, d)) {
+			boundary = other
+		}
+	}
+	return boundary
+}
+
+//boundaryIn returns the next multiple of d after now, counted in a zone offset of seconds east of UTC
+//Truncate counts from the zero time in UTC, so the offset is added first and taken off again afterwards
+func boundaryIn(now time.Time, offset int, d time.Duration) time.Time {
+	zone := time.Duration(offset) * time.Second
+	return now.Add(zone).Truncate(d).Add(d).Add(-zone)
+}
+
+//onBoundary tells if the wall clock shows a multiple of d at t, in the offset in effect at t
+func onBoundary(t time.Time, d time.Duration) bool {
+	_, offset := t.Zone()
+	wall := t.Add(time.Duration(offset) * time.Second)
+	return wall.Truncate(d).Equal(wall)
+}
+
+// AfterFuncPrecise always gives the callback a timer of its own, bypassing the cache like time.AfterFunc would
+// use it for the few latency sensitive timeouts next to bulk timeouts that are fine with the cache window
+// the returned CancelFunc stops the timer
+func (t *Timeout) AfterFuncPrecise(d time.Duration, callback TimeoutCallback) CancelFunc {
+	return t.scheduleOrReport(d, &callbackSlot{callback: callback, precise: true})
+}
+
+// AfterFuncNotBefore works like AfterFuncCancellable, but the callback never fires before d, e.g. to enforce a minimum timeout
+// where AfterDuration may fire up to a cache window early, this fires between d and d+Granularity+CacheWindow
+// the entries are cached apart from those of AfterDuration, the two never share an entry
+func (t *Timeout) AfterFuncNotBefore(d time.Duration, callback TimeoutCallback) CancelFunc {
+	return t.scheduleOrReport(d, &callbackSlot{callback: callback, notBefore: true})
+}
+
+// AfterFuncWindow works like AfterFuncCancellable, but only joins an entry no older than window instead of CacheWindow
+// so the callback fires between d-window and d, a zero or negative window never joins an entry, like AfterFuncPrecise
+// an entry too old for the window is replaced by a fresh one, which callbacks with a wider window then join as well
+// the replaced entry keeps the callbacks it had and still fires for them
+func (t *Timeout) AfterFuncWindow(d, window time.Duration, callback TimeoutCallback) CancelFunc {
+	if window <= 0 {
+		return t.AfterFuncPrecise(d, callback)
+	}
+	return t.scheduleOrReport(d, &callbackSlot{callback: callback, window: window})
+}
+
+// CallbackRef refers to a callback scheduled with AfterFuncRef, it is a plain value so scheduling allocates no closure
+// a ref stays valid until its entry fired, from then on Cancel is a no-op, the zero CallbackRef is never valid
+type CallbackRef struct {
+	entry *timeoutEntry
+	slot  *callbackSlot
+}
+
+// AfterFuncRef works like AfterFuncCancellable, but returns a CallbackRef to pass to Cancel instead of a closure
+func (t *Timeout) AfterFuncRef(seconds int, callback TimeoutCallback) CallbackRef {
+	entry, slot, err := t.place(secondsToDuration(seconds), t.userSlot(callback))
+	if err != nil {
+		t.report(err)
+	}
+	if entry == nil {
+		return CallbackRef{}
+	}
+	return CallbackRef{entry: entry, slot: slot}
+}
+
+// Cancel prevents the callback from running, it returns false if the callback already ran or was cancelled before
+// true guarantees the callback never runs, false that it ran, is running or is about to, unless Stop drops it or PanicStop skips it
+func (t *Timeout) Cancel(ref CallbackRef) bool {
+	if ref.entry == nil {
+		return false
+	}
+	cancelled := ref.entry.removeSlot(ref.slot)
+	if cancelled && ref.entry.unique {
+		t.stopUnique(ref.entry)
+	}
+	return cancelled
+}
+
+// AfterFuncCtx works like AfterFunc, but the callback receives a context that is cancelled when the Timeout is stopped
+// with Stop the context is cancelled right away, so long running callbacks can bail out, queued callbacks are dropped
+// with DrainAndStop the drained callbacks still get a live context, it is cancelled once they returned
+func (t *Timeout) AfterFuncCtx(seconds int, callback func(context.Context)) {
+	ctx, _ := t.context()
+	t.schedule(secondsToDuration(seconds), func() {
+		callback(ctx)
+	})
+}
+
+// Reserve makes room for at least n callbacks in the active entry for the timeout length, creating the entry if needed
+// it is a hint like the capacity of make, use it before a burst of callbacks to avoid growing the slice over and over
+// it is safe to call concurrently with scheduling
+func (t *Timeout) Reserve(seconds int, n int) {
+	d := secondsToDuration(seconds)
+	if n <= 0 || t.stopped.Load() || !t.shares(d) {
+		//a unique entry per callback has nothing to reserve
+		return
+	}
+	for {
+		entry, created := t.entryFor(d, t.cacheWindow())
+		if created {
+			t.created(entry)
+		}
+		if entry.reserve(n) {
+			return
+		}
+		//fired in between, reserve in a fresh entry
+		if t.stopped.Load() {
+			return
+		}
+		t.release(entry)
+	}
+}
+
+// Prewarm creates the entries for the timeout lengths up front, so the first callbacks for them join an existing entry
+// a prewarmed entry is empty and expires like any other, its timer fires without callbacks, which costs next to nothing
+// lengths that get a unique timer per callback are skipped, there is no entry to share
+func (t *Timeout) Prewarm(seconds ...int) {
+	for _, s := range seconds {
+		d := secondsToDuration(s)
+		if t.stopped.Load() {
+			return
+		}
+		if t.shares(d) {
+			if entry, created := t.entryFor(d, t.cacheWindow()); created {
+				t.created(entry)
+			}
+		}
+	}
+}
+
+//shares tells if callbacks for d share an entry, rather than getting a unique timer each
+func (t *Timeout) shares(d time.Duration) bool {
+	if d <= 0 || t.rejects(d) {
+		return false
+	}
+	if t.fineFor(d) {
+		return true
+	}
+	if _, cached := t.bucketFor(d); cached {
+		return true
+	}
+	return d >= t.granularity() && t.Fallback != FallbackUnique
+}
+
+// SignalAfter sends on ch once the timeout fires, it is AfterFunc for the common case of signalling a channel
+// the channel is kept in the entry as is, so unlike a closure sending on it scheduling allocates nothing but the slot
+// the send never blocks the entry, if ch is full and nobody is receiving the signal is dropped, so ch is usually buffered
+func (t *Timeout) SignalAfter(seconds int, ch chan<- struct{}) {
+	//no CancelFunc is handed out, so none is built
+	if _, _, err := t.place(secondsToDuration(seconds), &callbackSlot{signal: ch}); err != nil {
+		t.report(err)
+	}
+}
+
+// After works like time.After, but shares the cached timers used by AfterFunc
+// the channel is buffered, so the entry never blocks on it, and receives the time the entry fired
+func (t *Timeout) After(seconds int) <-chan time.Time {
+	c := make(chan time.Time, 1)
+	t.schedule(secondsToDuration(seconds), func() {
+		c <- t.now()
+	})
+	return c
+}
+
+// AfterFuncContext works like AfterFunc, but the callback is tied to ctx
+// if ctx is done before the timeout fires, the callback is removed from its entry and never invoked
+// only this callback is skipped, other callbacks sharing the same entry are not affected
+func (t *Timeout) AfterFuncContext(ctx context.Context, seconds int, callback TimeoutCallback) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	var mu sync.Mutex
+	var stop func() bool
+	cancel := t.schedule(secondsToDuration(seconds), func() {
+		if ctx.Err() != nil {
+			return
+		}
+		//the timeout won, no need to keep watching ctx
+		mu.Lock()
+		if stop != nil {
+			stop()
+		}
+		mu.Unlock()
+		callback()
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	stop = context.AfterFunc(ctx, cancel)
+}
+
+func (t *Timeout) clock() Clock {
+	if t.Clock != nil {
+		return t.Clock
+	}
+	return realClock{}
+}
+
+func (t *Timeout) now() time.Time {
+	return t.clock().Now()
+}
+
+func (t *Timeout) granularity() time.Duration {
+	if t.Granularity > 0 {
+		return t.Granularity
+	}
+	return defaultGranularity
+}
+
+func (t *Timeout) cacheWindow() time.Duration {
+	if t.CacheWindow > 0 {
+		return t.CacheWindow
+	}
+	return defaultCacheWindow
+}
+
+//entrySlots is an entries array, SetMaxSeconds replaces it as a whole so a reader never sees one that is half resized
+type entrySlots []atomic.Pointer[timeoutEntry]
+
+//bucketCount is the length of the entries array caching timeouts up to seconds
+func (t *Timeout) bucketCount(seconds int) int {
+	if seconds <= 0 {
+		seconds = defaultMaxSeconds
+	}
+	return int(secondsToDuration(seconds)/t.granularity()) + 1
+}
+
+//getEntries returns the current entries array, callers index the slice they got rather than loading it again
+func (t *Timeout) getEntries() []atomic.Pointer[timeoutEntry] {
+	t.entriesOnce.Do(func() {
+		entries := make(entrySlots, t.bucketCount(t.MaxSeconds))
+		t.entries.Store(&entries)
+	})
+	return *t.entries.Load()
+}
+
+func (t *Timeout) getNotBeforeEntries() []atomic.Pointer[timeoutEntry] {
+	t.notBeforeOnce.Do(func() {
+		t.resizeMu.Lock()
+		defer t.resizeMu.Unlock()
+		entries := make(entrySlots, len(t.getEntries()))
+		t.notBefore.Store(&entries)
+	})
+	return *t.notBefore.Load()
+}
+
+// SetMaxSeconds changes the longest timeout that is cached at runtime, growing or shrinking the entries array
+// it is safe to call while callbacks are scheduled, nothing scheduled is lost:
+// entries beyond a shrunk range keep their timers and fire on time, later callbacks for their lengths follow Fallback
+// an entry created while the array is being copied may be left out of the new one, it still fires, later callbacks start a fresh entry
+// the MaxSeconds field keeps the value the Timeout was created with
+func (t *Timeout) SetMaxSeconds(seconds int) {
+	t.getNotBeforeEntries()
+	t.resizeMu.Lock()
+	defer t.resizeMu.Unlock()
+	n := t.bucketCount(seconds)
+	t.entries.Store(resized(t.getEntries(), n))
+	t.notBefore.Store(resized(*t.notBefore.Load(), n))
+}
+
+//resized copies the slots of entries into an array of length n
+func resized(entries []atomic.Pointer[timeoutEntry], n int) *entrySlots {
+	slots := make(entrySlots, n)
+	for i := range slots[:min(n, len(entries))] {
+		slots[i].Store(entries[i].Load())
+	}
+	return &slots
+}
+
+// Stop cancels all pending timers of the Timeout
+// callbacks already queued in an entry are dropped, they never run
+// any callback scheduled after Stop is dropped as well
+func (t *Timeout) Stop() {
+	t.shutdown(false)
+}
+
+// DrainAndStop stops the Timeout like Stop, but runs every callback still queued in an entry right away instead of dropping it
+// it returns once those callbacks, and any callbacks that were already firing, have returned
+// each callback runs exactly once, either drained here or fired by its timer if that got to it first
+// it must not be called from within a callback, as it would wait for itself
+func (t *Timeout) DrainAndStop() {
+	t.shutdown(true)
+}
+
+// Reset drops every pending callback and clears the cache and counters, the Timeout stays usable afterwards
+// callbacks scheduled concurrently either go into a fresh entry or are dropped with the rest
+// callbacks that already started firing still run, Reset does not wait for them and does not undo Stop
+func (t *Timeout) Reset() {
+	t.mu.Lock()
+	armed := t.armed
+	t.armed = nil
+	t.stats.activeEntries.Add(-int64(len(armed)))
+	t.mu.Unlock()
+	for entry := range armed {
+		entry.discard(false)
+	}
+
+	entries := t.getEntries()
+	for i := range entries {
+		entries[i].Store(nil)
+	}
+	notBefore := t.getNotBeforeEntries()
+	for i := range notBefore {
+		notBefore[i].Store(nil)
+	}
+	t.longMu.Lock()
+	t.long = nil
+	t.longLRU.Init()
+	t.longMu.Unlock()
+	t.resetMu.Lock()
+	t.resets = nil
+	t.resetMu.Unlock()
+	t.onceMu.Lock()
+	t.onces = nil
+	t.onceMu.Unlock()
+	t.dropThrottles(false)
+	t.idsMu.Lock()
+	t.ids = nil
+	t.resetID = t.lastID
+	t.idsMu.Unlock()
+
+	//the gauges follow the entries, only the counters start over
+	t.stats.cacheHits.Store(0)
+	t.stats.cacheMisses.Store(0)
+	t.stats.uniqueTimers.Store(0)
+	t.stats.fired.Store(0)
+	t.stats.droppedEvents.Store(0)
+	t.stats.longEvictions.Store(0)
+	t.stats.overflows.Store(0)
+	t.stats.timersSaved.Store(0)
+	t.stats.measured.Store(0)
+	t.stats.callbackTotal.Store(0)
+	t.stats.callbackMax.Store(0)
+}
+
+// CancelAll cancels every callback waiting for the timeout length and returns how many it cancelled, other lengths are left alone
+// it covers the entry AfterFunc(seconds) would join as well as older entries of the length that are still armed, their timers are stopped
+// callbacks with a timer of their own, e.g. from AfterFuncPrecise, AfterFuncNotBefore or FallbackUnique, are left alone, as is the fine wheel
+// a callback scheduled concurrently is either cancelled with the others or goes into a fresh entry and fires
+func (t *Timeout) CancelAll(seconds int) int {
+	d := secondsToDuration(seconds)
+	if d <= 0 {
+		return 0
+	}
+	bucket, cached := t.bucketFor(d)
+	if !cached && d >= t.granularity() && t.Fallback == FallbackClamp {
+		bucket, cached = len(t.getEntries())-1, true
+	}
+	key := t.longKey(d)
+	matches := func(entry *timeoutEntry) bool {
+		if entry.unique || entry.notBefore {
+			return false
+		}
+		if cached {
+			return entry.bucket == bucket
+		}
+		return entry.long != 0 && entry.long == key
+	}
+
+	t.mu.Lock()
+	var entries []*timeoutEntry
+	for entry := range t.armed {
+		if matches(entry) {
+			entries = append(entries, entry)
+			delete(t.armed, entry)
+		}
+	}
+	t.stats.activeEntries.Add(-int64(len(entries)))
+	t.mu.Unlock()
+
+	cancelled := 0
+	for _, entry := range entries {
+		//an entry that is firing right now keeps its callbacks
+		n := entry.discard(false)
+		t.release(entry)
+		if n > 0 {
+			t.emptied(entry)
+		}
+		cancelled += n
+	}
+	return cancelled
+}
+
+//context returns the context of the Timeout, it is done once the Timeout is stopped
+func (t *Timeout) context() (context.Context, context.CancelFunc) {
+	t.ctxOnce.Do(func() {
+		t.ctx, t.ctxCancel = context.WithCancel(context.Background())
+	})
+	return t.ctx, t.ctxCancel
+}
+
+func (t *Timeout) shutdown(drain bool) {
+	t.stopped.Store(true)
+	_, cancel := t.context()
+	if !drain {
+		//callbacks already running get told to bail out
+		cancel()
+	}
+	t.mu.Lock()
+	armed := t.armed
+	t.armed = nil
+	t.stats.activeEntries.Add(-int64(len(armed)))
+	t.mu.Unlock()
+	if t.Logger != nil {
+		t.logStop(drain, len(armed))
+	}
+
+	for entry := range armed {
+		if !drain {
+			entry.stop()
+			continue
+		}
+		if callbacks, ok := entry.drain(); ok {
+			//like a timer firing, on a goroutine of its own, Wait below waits for it
+			go entry.fireCallbacks(callbacks)
+		}
+	}
+	t.dropThrottles(drain)
+	if drain {
+		t.Wait()
+		cancel()
+	}
+	if t.wheel != nil {
+		t.wheel.stop()
+	}
+	if t.fine != nil {
+		t.fine.stop()
+	}
+	t.poolMu.Lock()
+	if pool := t.workerPool(); pool != nil {
+		pool.stop()
+	}
+	t.poolMu.Unlock()
+}
+
+func (t *Timeout) newEntry(d time.Duration) *timeoutEntry {
+	return &timeoutEntry{
+		timestamp: t.now(),
+		timeout:   d,
+		owner:     t,
+	}
+}
+
+//arm starts the timer of a new entry, tracked so Stop can cancel it
+func (t *Timeout) arm(entry *timeoutEntry) *timeoutEntry {
+	delay := entry.timeout
+	if !entry.unique && !entry.notBefore {
+		//only shared entries are jittered, a unique timer has no herd to spread out
+		//and jitter could make an entry of AfterFuncNotBefore fire early
+		delay = t.jitter(delay)
+	}
+	return t.armWith(entry, delay, t.afterFunc)
+}
+
+//armWith is arm on a given timer source, the fine wheel uses it to hand out its own timers
+func (t *Timeout) armWith(entry *timeoutEntry, delay time.Duration, afterFunc func(time.Duration, func()) Timer) *timeoutEntry {
+	if dropped := t.armLocked(entry, delay, afterFunc); len(dropped) > 0 {
+		t.dropped("gotimeout: callback dropped", entry.timeout, dropped...)
+	}
+	return entry
+}
+
+//armLocked is armWith under the lock of t and the entry, it returns the callbacks it dropped if it lost the race against Stop
+func (t *Timeout) armLocked(entry *timeoutEntry, delay time.Duration, afterFunc func(time.Duration, func()) Timer) []*callbackSlot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	//a cached entry is visible in its slot before it is armed, callbacks may already be joining it
+	entry.Lock()
+	defer entry.Unlock()
+	if t.stopped.Load() {
+		//lost the race against Stop, hand out an entry that drops everything
+		entry.completed = true
+		entry.stopped = true
+		entry.gather()
+		entry.releaseShards()
+		dropped := entry.callbacks
+		entry.callbacks = nil
+		entry.keys = nil
+		entry.funcs = nil
+		t.stats.pendingCallbacks.Add(-int64(entry.live))
+		return dropped
+	}
+	if t.armed == nil {
+		t.armed = make(map[*timeoutEntry]struct{})
+	}
+	t.armed[entry] = struct{}{}
+	t.pending.add()
+	t.stats.activeEntries.Add(1)
+	entry.deadline = entry.timestamp.Add(delay)
+	entry.timer = afterFunc(delay, func() { t.fire(entry) })
+	return nil
+}
+
+//goInvoke fires the slot on its own goroutine, Wait waits for it
+//it is skipped and not counted as fired if the cancel of an immediate callback claimed the slot first
+func (t *Timeout) goInvoke(te *timeoutEntry, slot *callbackSlot) {
+	t.pending.add()
+	go func() {
+		defer t.pending.done()
+		if !slot.claimed.CompareAndSwap(false, true) {
+			return
+		}
+		t.invokeTraced(slot.traceID, func() { t.fireSlot(te, slot) })
+		t.stats.fired.Add(1)
+	}()
+}
+
+//run invokes the callbacks of a fired entry and calls done once all of them returned
+func (t *Timeout) run(te *timeoutEntry, callbacks []*callbackSlot, done func()) {
+	if t.ExecutionOrder == LIFO {
+		//the slice is owned by the fired entry, it can be turned around in place
+		slices.Reverse(callbacks)
+	}
+	if t.OrderByDeadline {
+		sortByDeadline(callbacks)
+	}
+	if te.prioritized {
+		sortByPriority(callbacks)
+	}
+	if t.FairDispatch {
+		t.dispatcher.submitFair(t.stepInline(te, callbacks, done))
+		return
+	}
+	if t.SerialDispatch {
+		t.dispatcher.submit(te.deadline, func() { t.runInline(te, callbacks, done) })
+		return
+	}
+	pool := t.workerPool()
+	if pool == nil {
+		t.runInline(te, callbacks, done)
+		return
+	}
+
+	if len(callbacks) == 0 {
+		done()
+		return
+	}
+	//callbacks are handed to the pool in order, but may finish in any order
+	var remaining atomic.Int64
+	remaining.Store(int64(len(callbacks)))
+	var stopped atomic.Bool
+	for _, slot := range callbacks {
+		slot := slot
+		pool.submit(func() {
+			switch {
+			case !t.claim(slot):
+			case stopped.Load():
+				t.stats.pendingCallbacks.Add(-1)
+				t.dropped("gotimeout: callback skipped", te.timeout, slot)
+			default:
+				if t.stopsOn(t.call(te, slot)) {
+					stopped.Store(true)
+				}
+				t.stats.fired.Add(1)
+				t.stats.pendingCallbacks.Add(-1)
+			}
+			if remaining.Add(-1) == 0 {
+				done()
+			}
+		})
+	}
+}
+
+//call runs a callback of a fired entry, giving up on it once it exceeds CallbackTimeout
+//it returns true if the callback panicked, an abandoned callback does not count as panicked
+func (t *Timeout) call(te *timeoutEntry, slot *callbackSlot) bool {
+	if t.CallbackTimeout <= 0 {
+		return t.invokeTraced(slot.traceID, func() { t.fireSlot(te, slot) })
+	}
+	var panicked bool
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		panicked = t.invokeTraced(slot.traceID, func() { t.fireSlot(te, slot) })
+	}()
+
+	expired := make(chan struct{})
+	timer := t.timer(t.CallbackTimeout, func() { close(expired) })
+	defer timer.Stop()
+	select {
+	case <-finished:
+		return panicked
+	case <-expired:
+		t.reportTraced(ErrCallbackTimeout, slot.traceID)
+		return false
+	}
+}
+
+//claim takes a callback of a fired entry for running it, false if a cancel took it first and already accounted for it
+//the entry lock makes removeSlot give up on a fired entry, the flag keeps the guarantee of CancelFunc from relying on that alone
+func (t *Timeout) claim(slot *callbackSlot) bool {
+	return slot.claimed.CompareAndSwap(false, true)
+}
+
+//runInline is run without workers, on the calling goroutine
+func (t *Timeout) runInline(te *timeoutEntry, callbacks []*callbackSlot, done func()) {
+	step := t.stepInline(te, callbacks, done)
+	for step() {
+	}
+}
+
+//stepInline returns a func running the callbacks one per call, it tells if any are left and calls done after the last one
+//callbacks run one after the other in ExecutionOrder, removing a callback keeps the order of the rest
+func (t *Timeout) stepInline(te *timeoutEntry, callbacks []*callbackSlot, done func()) func() bool {
+	next := 0
+	return func() bool {
+		for next < len(callbacks) {
+			slot := callbacks[next]
+			next++
+			if !t.claim(slot) {
+				continue
+			}
+			panicked := t.call(te, slot)
+			t.stats.pendingCallbacks.Add(-1)
+			t.stats.fired.Add(1)
+			if t.stopsOn(panicked) {
+				t.stats.pendingCallbacks.Add(-int64(len(callbacks) - next))
+				t.dropped("gotimeout: callback skipped", te.timeout, callbacks[next:]...)
+				next = len(callbacks)
+			}
+			break
+		}
+		if next < len(callbacks) {
+			return true
+		}
+		done()
+		return false
+	}
+}
+
+func (t *Timeout) workerPool() *workerPool {
+	t.poolOnce.Do(func() {
+		if t.Workers > 0 {
+			t.pool.Store(newWorkerPool(t.Workers))
+		}
+	})
+	return t.pool.Load()
+}
+
+// SetWorkerCount changes the number of workers at runtime, zero goes back to running callbacks on the timer goroutine
+// removed workers finish the callback they are running first, so no callback is dropped, shrinking returns without waiting for them
+// it is safe to call from within a callback
+// it is ignored once the Timeout is stopped, the Workers field keeps the count the Timeout was created with
+func (t *Timeout) SetWorkerCount(n int) {
+	t.poolMu.Lock()
+	defer t.poolMu.Unlock()
+	if t.stopped.Load() {
+		return
+	}
+	pool := t.workerPool()
+	switch {
+	case n <= 0 && pool != nil:
+		//callbacks still waiting to be handed to a worker run on their timer goroutine instead
+		t.pool.Store(nil)
+		pool.stop()
+	case n > 0 && pool == nil:
+		t.pool.Store(newWorkerPool(n))
+	case n > 0:
+		pool.resize(n)
+	}
+}
+
+func (t *Timeout) afterFunc(d time.Duration, f func()) Timer {
+	if t.wheel != nil && t.TimerFunc == nil {
+		return t.wheel.AfterFunc(d, f)
+	}
+	return t.timer(d, f)
+}
+
+//timer arms a single timer on the configured timer source, ignoring the timing wheel
+func (t *Timeout) timer(d time.Duration, f func()) Timer {
+	if t.TimerFunc != nil {
+		return stopFunc(t.TimerFunc(d, f))
+	}
+	return t.clock().AfterFunc(d, f)
+}
+
+func (t *Timeout) fire(entry *timeoutEntry) {
+	if t.hold(entry) {
+		//paused, Resume triggers it
+		return
+	}
+	t.disarm(entry)
+	t.release(entry)
+	entry.trigger()
+}
+
+//spill makes room for n callbacks that found the entry at MaxCallbacksPerBucket, the entry keeps its callbacks and fires on time
+//releasing its slot lets the next lookup arm a fresh entry for the length, concurrent spills for the same entry all end up in that one
+//it returns false for an entry without a slot, like those of the fine wheel, the callbacks then need a unique timer
+func (t *Timeout) spill(entry *timeoutEntry, n int) bool {
+	t.stats.overflows.Add(int64(n))
+	if entry.bucket == 0 && entry.long == 0 {
+		return false
+	}
+	t.release(entry)
+	return true
+}
+
+//release clears the entry's slot so the entry and its callbacks can be collected
+//a cleared slot is treated like an empty one, the next schedule creates a fresh entry instead of joining a fired one
+func (t *Timeout) release(entry *timeoutEntry) {
+	if entry.long != 0 {
+		t.longMu.Lock()
+		if t.long[entry.long] == entry {
+			delete(t.long, entry.long)
+			t.longLRU.Remove(entry.longUse)
+		}
+		t.longMu.Unlock()
+		return
+	}
+	if entry.bucket == 0 {
+		return
+	}
+	//if a newer entry took the slot it is left alone, as is an array SetMaxSeconds shrunk below the bucket
+	entries := t.getEntries()
+	if entry.notBefore {
+		entries = t.getNotBeforeEntries()
+	}
+	if entry.bucket < len(entries) {
+		entries[entry.bucket].CompareAndSwap(entry, nil)
+	}
+}
+
+func (t *Timeout) disarm(entry *timeoutEntry) {
+	t.mu.Lock()
+	if _, ok := t.armed[entry]; ok {
+		delete(t.armed, entry)
+		t.stats.activeEntries.Add(-1)
+	}
+	t.mu.Unlock()
+}
+
+func (t *Timeout) jitter(d time.Duration) time.Duration {
+	if t.Jitter <= 0 {
+		return d
+	}
+	//a jitter beyond half the range of a time.Duration would overflow the span drawn from, it saturates instead
+	jitter := min(t.Jitter, time.Duration((math.MaxInt64-1)/2))
+	offset := time.Duration(rand.Int63n(int64(2*jitter)+1)) - jitter
+	if offset > 0 && d > math.MaxInt64-offset {
+		//already as long as a timeout gets, jittering it further would overflow
+		return d
+	}
+	d += offset
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func noop() {}
+
+//userSlot is the slot for a callback passed in by the user, the wrappers built by the variants are never deduplicated
+//as all closures created by the same function literal share their code pointer
+func (t *Timeout) userSlot(callback TimeoutCallback) *callbackSlot {
+	slot := &callbackSlot{callback: callback}
+	if t.DedupCallbacks {
+		slot.code = reflect.ValueOf(callback).Pointer()
+	}
+	return slot
+}
+
+func (t *Timeout) schedule(d time.Duration, callback TimeoutCallback) CancelFunc {
+	return t.scheduleOrReport(d, &callbackSlot{callback: callback})
+}
+
+//scheduleResult is schedule, also telling how the callback was placed
+func (t *Timeout) scheduleResult(d time.Duration, callback TimeoutCallback) (CancelFunc, ScheduleResult) {
+	cancel, result, err := t.scheduleSlotResult(d, &callbackSlot{callback: callback})
+	if err != nil {
+		t.report(err)
+	}
+	return cancel, result
+}
+
+//scheduleOrReport is scheduleSlot for the variants without an error result, the error goes to the ErrorHandler instead
+func (t *Timeout) scheduleOrReport(d time.Duration, slot *callbackSlot) CancelFunc {
+	cancel, err := t.scheduleSlot(d, slot)
+	if err != nil {
+		t.report(err)
+	}
+	return cancel
+}
+
+//report hands a problem that would otherwise go unnoticed to the ErrorHandler
+func (t *Timeout) report(err error) {
+	t.reportTraced(err, 0)
+}
+
+//reportTraced is report for a problem of a single callback, logged with its id of TraceCallbacks, 0 logs none
+func (t *Timeout) reportTraced(err error, id uint64) {
+	if t.Logger != nil {
+		t.logError(err, id)
+	}
+	if t.ErrorHandler != nil {
+		t.ErrorHandler(err)
+	}
+}
+
+func (t *Timeout) scheduleSlot(d time.Duration, slot *callbackSlot) (CancelFunc, error) {
+	cancel, _, err := t.scheduleSlotResult(d, slot)
+	return cancel, err
+}
+
+//scheduleSlotResult is scheduleSlot, also telling how the slot was placed
+func (t *Timeout) scheduleSlotResult(d time.Duration, slot *callbackSlot) (CancelFunc, ScheduleResult, error) {
+	entry, slot, result, err := t.placeResult(d, slot)
+	if entry == nil && slot != nil {
+		//ran right away on its own goroutine, cancelling races that goroutine
+		return func() { slot.claimed.Store(true) }, result, nil
+	}
+	if entry == nil {
+		return noop, result, err
+	}
+	if entry.unique {
+		return func() {
+			entry.removeSlot(slot)
+			t.stopUnique(entry)
+		}, result, nil
+	}
+	return func() { entry.removeSlot(slot) }, result, nil
+}
+
+//overloaded tells if n more callbacks would exceed MaxPendingCallbacks
+func (t *Timeout) overloaded(n int) bool {
+	return t.MaxPendingCallbacks > 0 && t.stats.pendingCallbacks.Load()+int64(n) > int64(t.MaxPendingCallbacks)
+}
+
+//stopUnique stops the timer of a unique entry once its callback is cancelled, nobody else shares it
+func (t *Timeout) stopUnique(entry *timeoutEntry) {
+	entry.stop()
+	t.disarm(entry)
+}
+
+//place puts the slot in the entry for d and returns both
+//the entry is nil if the callback did not go into one, the slot is still returned if it runs right away and nil if it was rejected
+func (t *Timeout) place(d time.Duration, slot *callbackSlot) (*timeoutEntry, *callbackSlot, error) {
+	entry, slot, _, err := t.placeResult(d, slot)
+	return entry, slot, err
+}
+
+//placeResult is place, also telling how the slot was placed
+func (t *Timeout) placeResult(d time.Duration, slot *callbackSlot) (*timeoutEntry, *callbackSlot, ScheduleResult, error) {
+	if t.stopped.Load() {
+		return nil, nil, ScheduleRejected, ErrStopped
+	}
+	if t.overloaded(1) {
+		return nil, nil, ScheduleRejected, ErrTooManyPending
+	}
+	if !slot.repeat && t.reentrant() {
+		return nil, nil, ScheduleRejected, ErrReentrant
+	}
+	if t.tracing() {
+		t.trace(slot)
+	}
+	if t.OrderByDeadline && slot.deadline.IsZero() {
+		//a callback placed again, e.g. when its entry was full, keeps its first deadline
+		slot.deadline = t.now().Add(d)
+	}
+
+	//no timeout, just invoke it, on its own goroutine so AfterFunc never runs the callback on the caller's stack
+	//negative timeouts are treated the same, they must never reach the entries index
+	if d <= 0 {
+		if slot.traceID != 0 {
+			t.logCallback("gotimeout: callback scheduled", slot.traceID, 0)
+		}
+		t.goInvoke(nil, slot)
+		return nil, slot, ScheduleImmediate, nil
+	}
+	if !slot.precise && t.rejects(d) {
+		return nil, nil, ScheduleRejected, ErrOutOfRange
+	}
+
+	window := t.cacheWindow()
+	if slot.window > 0 {
+		window = slot.window
+	}
+	var entry *timeoutEntry
+	var created, spilled bool
+	for {
+		if slot.precise || spilled {
+			entry, created = t.uniqueEntry(d), true
+		} else if slot.notBefore {
+			entry, created = t.notBeforeEntryFor(d)
+		} else {
+			entry, created = t.entryFor(d, window)
+		}
+		if created {
+			t.created(entry)
+		}
+		joined, result := entry.join(slot)
+		if result == joinStopped {
+			//raced with Stop
+			return nil, nil, ScheduleRejected, ErrStopped
+		}
+		if result == joinAdded {
+			if !created {
+				t.stats.cacheHits.Add(1)
+				t.stats.timersSaved.Add(1)
+			}
+			t.Hooks.added(entry.timeout, 1)
+			if joined.traceID != 0 {
+				t.logCallback("gotimeout: callback scheduled", joined.traceID, entry.timeout)
+			}
+			slot = joined
+			break
+		}
+		if result == joinFull {
+			spilled = !t.spill(entry, 1)
+			continue
+		}
+		//the entry fired between looking it up and joining it, its deadline has not been ours for a while
+		//so rather than running the callback early, it goes into a fresh entry armed by the next lookup
+		//fire releases the slot before triggering, releasing it here as well makes sure a completed entry is never looked up again
+		//a callback scheduling the same length from inside trigger thus always gets a new entry, due d from now
+		t.release(entry)
+	}
+	switch {
+	case entry.unique:
+		return entry, slot, ScheduleUnique, nil
+	case created:
+		return entry, slot, ScheduleCreated, nil
+	}
+	return entry, slot, ScheduleJoined, nil
+}
+
+func (t *Timeout) scheduleBatch(d time.Duration, callbacks []TimeoutCallback) {
+	if len(callbacks) == 0 {
+		return
+	}
+	if t.stopped.Load() {
+		t.report(ErrStopped)
+		return
+	}
+	if t.overloaded(len(callbacks)) {
+		t.report(ErrTooManyPending)
+		return
+	}
+	if t.reentrant() {
+		t.report(ErrReentrant)
+		return
+	}
+
+	if d <= 0 {
+		for _, callback := range callbacks {
+			t.goInvoke(nil, &callbackSlot{callback: callback})
+		}
+		return
+	}
+	if t.rejects(d) {
+		t.report(ErrOutOfRange)
+		return
+	}
+
+	slots := make([]*callbackSlot, len(callbacks))
+	var deadline time.Time
+	if t.OrderByDeadline {
+		deadline = t.now().Add(d)
+	}
+	for i, callback := range callbacks {
+		slots[i] = t.userSlot(callback)
+		slots[i].deadline = deadline
+		if t.tracing() {
+			t.trace(slots[i])
+		}
+	}
+	for {
+		entry, created := t.entryFor(d, t.cacheWindow())
+		if created {
+			t.created(entry)
+		}
+		n, result := entry.joinAll(slots)
+		switch result {
+		case joinStopped:
+			t.report(ErrStopped)
+			return
+		case joinAdded:
+			//callbacks skipped by DedupCallbacks did not join, they are not counted
+			joined := n
+			if created && joined > 0 {
+				joined--
+			}
+			t.stats.cacheHits.Add(int64(joined))
+			t.stats.timersSaved.Add(int64(joined))
+			t.Hooks.added(entry.timeout, n)
+			if t.tracing() {
+				for _, slot := range slots[:n] {
+					t.logCallback("gotimeout: callback scheduled", slot.traceID, entry.timeout)
+				}
+			}
+			return
+		case joinFull:
+			if !t.spill(entry, len(slots)) {
+				//fresh entries of the fine wheel would fill up the same way, the batch gets a unique one
+				if _, result := t.uniqueEntry(d).joinAll(slots); result == joinStopped {
+					t.report(ErrStopped)
+				}
+				return
+			}
+			continue
+		}
+		//fired in between, retry with a fresh entry like scheduleSlot does
+		t.release(entry)
+	}
+}
+
+//the longest timeout in seconds that still fits a time.Duration, with room to round it
+const maxTimeoutSeconds = int64(math.MaxInt64/time.Second) - 1
+
+//secondsToDuration converts the seconds passed to the scheduling functions, every seconds based variant goes through it
+//a timeout too long for a time.Duration would overflow into a negative one and fire right away, it is clamped to the longest one instead
+//a negative one would overflow the other way and wait for centuries, it is clamped as well and still runs right away
+func secondsToDuration(seconds int) time.Duration {
+	if int64(seconds) > maxTimeoutSeconds {
+		return time.Duration(maxTimeoutSeconds) * time.Second
+	}
+	if int64(seconds) < -maxTimeoutSeconds {
+		return -time.Duration(maxTimeoutSeconds) * time.Second
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+//roundTo returns d in whole units, rounded to the nearest one, without the overflow of adding half a unit first
+func roundTo(d, unit time.Duration) int64 {
+	n := int64(d / unit)
+	if d%unit >= unit-unit/2 {
+		n++
+	}
+	return n
+}
+
+//round is roundTo in the RoundingMode of t
+func (t *Timeout) round(d, unit time.Duration) int64 {
+	switch t.Rounding {
+	case RoundFloor:
+		return int64(d / unit)
+	case RoundCeil:
+		n := int64(d / unit)
+		if d%unit != 0 {
+			n++
+		}
+		return n
+	}
+	return roundTo(d, unit)
+}
+
+//longKey is the key of d in the map of timeouts beyond the cached range, whole seconds in the RoundingMode of t
+func (t *Timeout) longKey(d time.Duration) int64 {
+	return min(t.round(d, time.Second), maxTimeoutSeconds)
+}
+
+//bucketFor returns the index in entries for d, and false if d is outside of the cached range
+func (t *Timeout) bucketFor(d time.Duration) (int, bool) {
+	return t.bucketIn(t.getEntries(), d)
+}
+
+//bucketIn is bucketFor for an entries array the caller already holds, use it to index that array
+//it is the only place mapping durations to indexes, so nothing else has to guard the bounds of entries
+func (t *Timeout) bucketIn(entries []atomic.Pointer[timeoutEntry], d time.Duration) (int, bool) {
+	if d <= 0 {
+		return 0, false
+	}
+	bucket := t.round(d, t.granularity())
+	if bucket <= 0 || bucket >= int64(len(entries)) {
+		return 0, false
+	}
+	return int(bucket), true
+}
+
+//fineFor tells if d goes to the fine wheel, a TimerFunc takes precedence over it like over the timing wheel
+func (t *Timeout) fineFor(d time.Duration) bool {
+	return t.fine != nil && t.TimerFunc == nil && d <= t.fine.horizon
+}
+
+//rejects tells if d is beyond the cached range and Fallback is FallbackError
+func (t *Timeout) rejects(d time.Duration) bool {
+	if t.Fallback != FallbackError || t.fineFor(d) {
+		return false
+	}
+	_, cached := t.bucketFor(d)
+	return !cached && d >= t.granularity()
+}
+
+//uniqueEntry arms an entry that is not cached, so it fires after exactly d like time.AfterFunc
+func (t *Timeout) uniqueEntry(d time.Duration) *timeoutEntry {
+	t.stats.uniqueTimers.Add(1)
+	entry := t.newEntry(d)
+	entry.unique = true
+	return t.arm(entry)
+}
+
+//entryFor returns the entry callbacks for d go into, and whether it was created for them
+//d is rounded to a bucket in the RoundingMode, durations that round to no bucket get a unique entry
+//window is how old an entry may be to still be joined, CacheWindow unless overridden by AfterFuncWindow
+func (t *Timeout) entryFor(d, window time.Duration) (*timeoutEntry, bool) {
+	if t.fineFor(d) {
+		return t.fine.entryFor(t, d)
+	}
+	entries := t.getEntries()
+	bucket, cached := t.bucketIn(entries, d)
+	if !cached {
+		if d < t.granularity() {
+			//just use a unique instance, there is no bucket to share
+			return t.uniqueEntry(d), true
+		}
+		switch t.Fallback {
+		case FallbackUnique:
+			return t.uniqueEntry(d), true
+		case FallbackClamp:
+			bucket = len(entries) - 1
+		default:
+			return t.longEntryFor(d, window)
+		}
+	}
+
+	if t.Coalesce > 0 {
+		if entry := t.nearbyEntry(entries, bucket, window); entry != nil {
+			return entry, false
+		}
+	}
+	return t.cachedEntry(&entries[bucket], bucket, window, func() *timeoutEntry {
+		return t.newEntry(time.Duration(bucket) * t.granularity())
+	})
+}
+
+//created tells OnCreate about an entry a lookup created, outside of any lock
+//unique entries are left out, they are not buckets and are counted by UniqueTimers
+func (t *Timeout) created(entry *timeoutEntry) {
+	if onCreate := t.OnCreate; onCreate != nil && !entry.unique {
+		t.invoke(func() { onCreate(entry.timeout) })
+	}
+}
+
+//nearbyEntry returns the entry nearest to bucket within Coalesce that can still be joined, nil if bucket has one of its own or none is near
+func (t *Timeout) nearbyEntry(entries []atomic.Pointer[timeoutEntry], bucket int, window time.Duration) *timeoutEntry {
+	now := t.now()
+	if entry := entries[bucket].Load(); entry != nil && !entry.expired(now, window) {
+		return nil
+	}
+	span := int(secondsToDuration(t.Coalesce) / t.granularity())
+	for distance := 1; distance <= span; distance++ {
+		//the longer length first, firing late rather than early on a tie
+		for _, near := range [2]int{bucket + distance, bucket - distance} {
+			if near <= 0 || near >= len(entries) {
+				continue
+			}
+			if entry := entries[near].Load(); entry != nil && !entry.expired(now, window) {
+				return entry
+			}
+		}
+	}
+	return nil
+}
+
+//notBeforeEntryFor is entryFor for AfterFuncNotBefore, d is rounded up to a bucket and the entry fires a cache window later
+//so even a callback that joins the entry at the end of its window does not fire before d
+func (t *Timeout) notBeforeEntryFor(d time.Duration) (*timeoutEntry, bool) {
+	entries := t.getNotBeforeEntries()
+	granularity := t.granularity()
+	bucket := int64(d / granularity)
+	if d%granularity != 0 {
+		bucket++
+	}
+	if bucket >= int64(len(entries)) {
+		//a unique timer never fires early either
+		return t.uniqueEntry(d), true
+	}
+	return t.cachedEntry(&entries[bucket], int(bucket), t.cacheWindow(), func() *timeoutEntry {
+		entry := t.newEntry(time.Duration(bucket)*granularity + t.cacheWindow())
+		entry.notBefore = true
+		return entry
+	})
+}
+
+//cachedEntry returns the entry in the slot, or arms the one built by create if the slot is empty or older than window
+//an entry replaced for a narrower window keeps its callbacks and fires on its own, later callbacks join the newer one
+func (t *Timeout) cachedEntry(slot *atomic.Pointer[timeoutEntry], bucket int, window time.Duration, create func() *timeoutEntry) (*timeoutEntry, bool) {
+	var fresh *timeoutEntry
+	for {
+		//fetch entry from entry array
+		entry := slot.Load()
+		now := t.now()
+		if entry != nil && !entry.expired(now, window) {
+			return entry, false
+		}
+
+		//if entry doesn't exist, or if entry has expired, recreate it
+		if fresh == nil {
+			fresh = create()
+			fresh.bucket = bucket
+		}
+		//nobody saw the entry of a lost round, it is reused rather than allocated again
+		fresh.timestamp = now
+		//two goroutines may both create an entry here, only the one that swaps it into the slot arms it
+		//the loser retries and joins the winner, so there is never more than one timer per bucket
+		//the callbacks of the loser thus always go into the entry that got the slot, never into one about to be discarded
+		if slot.CompareAndSwap(entry, fresh) {
+			t.stats.cacheMisses.Add(1)
+			return t.arm(fresh), true
+		}
+	}
+}
+
+//longEntryFor is entryFor for timeouts beyond the cached range
+//they are coalesced by whole seconds in a map that only holds lengths that are in use, fired entries remove themselves
+//with MaxLongEntries the map is bounded, the least recently joined length is evicted to make room
+func (t *Timeout) longEntryFor(d, window time.Duration) (*timeoutEntry, bool) {
+	key := t.longKey(d)
+
+	t.longMu.Lock()
+	defer t.longMu.Unlock()
+	entry := t.long[key]
+	if entry != nil && !entry.expired(t.now(), window) {
+		t.longLRU.MoveToFront(entry.longUse)
+		return entry, false
+	}
+	if entry != nil {
+		//expired, it still fires but is replaced for new callbacks
+		t.longLRU.Remove(entry.longUse)
+	}
+
+	t.stats.cacheMisses.Add(1)
+	entry = t.newEntry(time.Duration(key) * time.Second)
+	entry.long = key
+	t.arm(entry)
+	if t.long == nil {
+		t.long = make(map[int64]*timeoutEntry)
+	}
+	t.long[key] = entry
+	entry.longUse = t.longLRU.PushFront(entry)
+	for t.MaxLongEntries > 0 && len(t.long) > t.MaxLongEntries {
+		//like an expired entry the evicted one keeps its callbacks and fires them, it just takes no new ones
+		evicted := t.longLRU.Remove(t.longLRU.Back()).(*timeoutEntry)
+		delete(t.long, evicted.long)
+		t.stats.longEvictions.Add(1)
+	}
+	return entry, true
 }
@@ -0,0 +1,1373 @@
+package gotimeout_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+	"github.com/asynkron/gotimeout/gotimeouttest"
+)
+
+//newFakeTimeout returns a Timeout driven by a FakeClock, its callbacks run from within Advance
+func newFakeTimeout(opts ...gotimeout.Option) (*gotimeout.Timeout, *gotimeouttest.FakeClock) {
+	clock := gotimeouttest.NewFakeClock(time.Unix(0, 0))
+	return gotimeout.MustNewTimeout(append([]gotimeout.Option{gotimeout.WithClock(clock)}, opts...)...), clock
+}
+
+//waitFor fails the test if done is not closed within a second, for callbacks that run on a goroutine of their own
+func waitFor(t *testing.T, done <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s did not run", what)
+	}
+}
+
+func TestAfterFuncBoundaries(t *testing.T) {
+	const maxSeconds = 10
+	for _, seconds := range []int{maxSeconds - 1, maxSeconds, maxSeconds + 1} {
+		to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(maxSeconds))
+		var fired atomic.Bool
+		to.AfterFunc(seconds, func() { fired.Store(true) })
+
+		clock.Advance(time.Duration(seconds)*time.Second - time.Millisecond)
+		if fired.Load() {
+			t.Fatalf("AfterFunc(%d) fired early", seconds)
+		}
+		clock.Advance(time.Millisecond)
+		if !fired.Load() {
+			t.Fatalf("AfterFunc(%d) did not fire at its deadline", seconds)
+		}
+		if s := to.Stats(); s.ActiveEntries != 0 || s.Pending != 0 {
+			t.Fatalf("AfterFunc(%d) left %+v behind", seconds, s)
+		}
+	}
+}
+
+func TestAfterFuncZeroAndNegative(t *testing.T) {
+	to, _ := newFakeTimeout(gotimeout.WithMaxSeconds(10))
+	//the most negative seconds would overflow a time.Duration into a timeout of centuries
+	for _, seconds := range []int{0, -1, -9223372037, math.MinInt} {
+		done := make(chan struct{})
+		to.AfterFunc(seconds, func() { close(done) })
+		waitFor(t, done, "negative or zero timeout")
+	}
+	if s := to.Stats(); s.ActiveEntries != 0 {
+		t.Fatalf("%d entries armed for timeouts that should run right away", s.ActiveEntries)
+	}
+}
+
+func TestAfterFuncOverflow(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10))
+	var fired atomic.Int64
+	//each of these computes to more than a time.Duration holds, none may wrap around and fire right away
+	to.AfterFunc(math.MaxInt, func() { fired.Add(1) })
+	to.AfterFunc(int(math.MaxInt64/time.Second), func() { fired.Add(1) })
+	to.AfterFunc(int(math.MaxInt64/time.Second)+1, func() { fired.Add(1) })
+	to.AfterDuration(math.MaxInt64, func() { fired.Add(1) })
+	if err := to.TryAfterFunc(math.MaxInt, func() { fired.Add(1) }); err != nil {
+		t.Fatalf("TryAfterFunc(math.MaxInt) = %v", err)
+	}
+
+	clock.Advance(100 * 365 * 24 * time.Hour)
+	if n := fired.Load(); n != 0 {
+		t.Fatalf("%d overflowing timeouts fired within a century", n)
+	}
+	if s := to.Stats(); s.Pending != 5 {
+		t.Fatalf("%d callbacks pending, want 5", s.Pending)
+	}
+}
+
+func TestJitterOverflow(t *testing.T) {
+	for _, jitter := range []time.Duration{math.MaxInt64 / 2, math.MaxInt64/2 + 1, math.MaxInt64} {
+		to, clock := newFakeTimeout(gotimeout.WithJitter(jitter))
+		var fired atomic.Bool
+		//drawing the offset must neither panic nor wrap the deadline around
+		to.AfterFunc(math.MaxInt, func() { fired.Store(true) })
+		to.AfterDuration(time.Second, func() {})
+		clock.Advance(time.Hour)
+		if fired.Load() {
+			t.Fatalf("jitter %d made AfterFunc(math.MaxInt) fire", jitter)
+		}
+	}
+}
+
+func TestDedupCallbacksBatch(t *testing.T) {
+	var added int
+	hooks := &gotimeout.TestingHooks{Added: func(_ time.Duration, n int) { added = n }}
+	to, clock := newFakeTimeout(gotimeout.WithDedupCallbacks(), gotimeout.WithTestingHooks(hooks))
+	var n, other atomic.Int64
+	f := func() { n.Add(1) }
+	g := func() { other.Add(1) }
+	to.AfterFunc(1, f)
+	to.AfterFunc(1, f)
+	to.AfterFuncBatch(1, []gotimeout.TimeoutCallback{f, g, f})
+	//only g joined, the copies of f were skipped
+	if added != 1 {
+		t.Fatalf("Added reported %d callbacks for the batch, want 1", added)
+	}
+	clock.Advance(time.Second)
+	if got := n.Load(); got != 1 {
+		t.Fatalf("deduplicated callback ran %d times, want 1", got)
+	}
+	if got := other.Load(); got != 1 {
+		t.Fatalf("batched callback ran %d times, want 1", got)
+	}
+}
+
+func TestCancelImmediate(t *testing.T) {
+	to, _ := newFakeTimeout()
+	var ran atomic.Int64
+	for i := 0; i < 100; i++ {
+		//the cancel races the goroutine running the callback, whichever wins Fired must agree
+		to.AfterFuncCancellable(0, func() { ran.Add(1) })()
+	}
+	to.Wait()
+	if s := to.Stats(); s.Fired != ran.Load() {
+		t.Fatalf("Fired = %d, but %d callbacks ran", s.Fired, ran.Load())
+	}
+}
+
+type countingTracer struct {
+	fired, ended atomic.Int64
+}
+
+func (c *countingTracer) Fire(timeout time.Duration, callbacks int) func() {
+	c.fired.Add(1)
+	return func() { c.ended.Add(1) }
+}
+
+func TestDrainNotifies(t *testing.T) {
+	tracer := &countingTracer{}
+	var triggered atomic.Int64
+	to, _ := newFakeTimeout(gotimeout.WithTracer(tracer), gotimeout.WithOnTrigger(func(_ time.Duration, count int) {
+		triggered.Add(int64(count))
+	}))
+	events := to.Events()
+	var ran atomic.Int64
+	to.AfterFunc(5, func() { ran.Add(1) })
+	to.AfterFunc(5, func() { ran.Add(1) })
+
+	//a drained entry fires like one whose timer ran out
+	to.DrainAndStop()
+	if got := ran.Load(); got != 2 {
+		t.Fatalf("%d of 2 callbacks drained", got)
+	}
+	if got := triggered.Load(); got != 2 {
+		t.Fatalf("OnTrigger saw %d callbacks, want 2", got)
+	}
+	if tracer.fired.Load() != 1 || tracer.ended.Load() != 1 {
+		t.Fatalf("Tracer fired %d and ended %d spans, want 1 each", tracer.fired.Load(), tracer.ended.Load())
+	}
+	select {
+	case ev := <-events:
+		if ev.Timeout != 5*time.Second || ev.Callbacks != 2 {
+			t.Fatalf("event %+v for the drained entry", ev)
+		}
+	default:
+		t.Fatal("no event for the drained entry")
+	}
+	if s := to.Stats(); s.Fired != 2 || s.Pending != 0 {
+		t.Fatalf("Stats %+v after DrainAndStop", s)
+	}
+}
+
+//advancing moves clock forward a millisecond at a time until the returned func is called, which waits for it to stop
+func advancing(clock *gotimeouttest.FakeClock) (stop func()) {
+	quit := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-quit:
+				return
+			default:
+				clock.Advance(time.Millisecond)
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-stopped
+	}
+}
+
+func TestScheduleWhileFiring(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithGranularity(10*time.Millisecond), gotimeout.WithCacheWindow(50*time.Millisecond))
+	const goroutines, perGoroutine = 8, 500
+	var fired atomic.Int64
+	stop := advancing(clock)
+	//entries keep firing while callbacks join them, a callback that lost the race to the trigger runs right away
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				to.AfterDuration(time.Duration(10+i%5*10)*time.Millisecond, func() { fired.Add(1) })
+			}
+		}()
+	}
+	wg.Wait()
+	stop()
+	clock.Advance(time.Second)
+	to.Wait()
+	if got := fired.Load(); got != goroutines*perGoroutine {
+		t.Fatalf("%d of %d callbacks fired", got, goroutines*perGoroutine)
+	}
+}
+
+func TestFIFOOrder(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var order []int
+	for i := 0; i < 100; i++ {
+		i := i
+		cancel := to.AfterFuncCancellable(1, func() { order = append(order, i) })
+		if i%10 == 5 {
+			//a cancelled callback leaves a hole, the others keep their order
+			cancel()
+		}
+	}
+	clock.Advance(time.Second)
+	if len(order) != 90 {
+		t.Fatalf("%d of 90 callbacks ran", len(order))
+	}
+	for i := 1; i < len(order); i++ {
+		if order[i] <= order[i-1] {
+			t.Fatalf("callbacks ran out of order: %v", order)
+		}
+	}
+}
+
+func TestFIFOOrderConcurrent(t *testing.T) {
+	to, clock := newFakeTimeout()
+	const goroutines, n = 8, 500
+	var order [goroutines][]int
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				i := i
+				to.AfterFunc(1, func() { order[g] = append(order[g], i) })
+			}
+		}(g)
+	}
+	wg.Wait()
+	clock.Advance(time.Second)
+	//goroutines joining at once go through different shards, each goroutine's callbacks still run in the order it scheduled them
+	for g := range order {
+		if len(order[g]) != n {
+			t.Fatalf("goroutine %d: %d of %d callbacks ran", g, len(order[g]), n)
+		}
+		for i, got := range order[g] {
+			if got != i {
+				t.Fatalf("goroutine %d: callbacks ran out of order: %v", g, order[g])
+			}
+		}
+	}
+}
+
+func TestEntryReplacement(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var first, second atomic.Bool
+	to.AfterFunc(1, func() { first.Store(true) })
+	//past the cache window the slot gets a fresh entry, the replaced one keeps its callback and its timer
+	clock.Advance(600 * time.Millisecond)
+	to.AfterFunc(1, func() { second.Store(true) })
+
+	clock.Advance(400 * time.Millisecond)
+	if !first.Load() || second.Load() {
+		t.Fatalf("at 1s first fired %v, second fired %v, want true, false", first.Load(), second.Load())
+	}
+	clock.Advance(600 * time.Millisecond)
+	if !second.Load() {
+		t.Fatal("the callback of the replacing entry did not fire")
+	}
+	if s := to.Stats(); s.ActiveEntries != 0 || s.CacheMisses != 2 {
+		t.Fatalf("Stats %+v, want 2 entries that both fired", s)
+	}
+}
+
+func TestEntryReplacementConcurrent(t *testing.T) {
+	var triggered atomic.Int64
+	to, clock := newFakeTimeout(gotimeout.WithCacheWindow(5*time.Millisecond), gotimeout.WithOnTrigger(func(_ time.Duration, count int) {
+		triggered.Add(int64(count))
+	}))
+	const goroutines, perGoroutine = 8, 200
+	var fired atomic.Int64
+	stop := advancing(clock)
+	//entries are replaced every few milliseconds while callbacks race into them
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				to.AfterDuration(20*time.Millisecond, func() { fired.Add(1) })
+			}
+		}()
+	}
+	wg.Wait()
+	stop()
+	clock.Advance(time.Second)
+	to.Wait()
+	const want = goroutines * perGoroutine
+	if got := fired.Load(); got != want {
+		t.Fatalf("%d of %d callbacks fired", got, want)
+	}
+	//callbacks that raced into an entry after it fired ran on their own, all others were fired by their entry
+	if got := triggered.Load(); got > want {
+		t.Fatalf("entries fired %d callbacks, more than the %d scheduled", got, want)
+	}
+	if s := to.Stats(); s.ActiveEntries != 0 || s.Pending != 0 {
+		t.Fatalf("Stats %+v once everything fired", s)
+	}
+}
+
+//scheduleAtOnce calls schedule from n goroutines released at the same moment
+func scheduleAtOnce(n int, schedule func()) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			schedule()
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestConcurrentCreation(t *testing.T) {
+	const n = 1000
+	for round := 0; round < 5; round++ {
+		to, clock := newFakeTimeout()
+		var fired atomic.Int64
+		scheduleAtOnce(n, func() {
+			to.AfterFunc(1, func() { fired.Add(1) })
+			to.AfterFuncPrecise(time.Second, func() { fired.Add(1) })
+		})
+		clock.Advance(time.Second)
+		if got := fired.Load(); got != 2*n {
+			t.Fatalf("round %d: %d of %d callbacks fired", round, got, 2*n)
+		}
+		//firing again must not find anything left over
+		clock.Advance(time.Hour)
+		if got := fired.Load(); got != 2*n {
+			t.Fatalf("round %d: %d callbacks fired, some more than once", round, got)
+		}
+	}
+}
+
+func TestConcurrentCreationOneTimer(t *testing.T) {
+	var triggers, triggered atomic.Int64
+	to, clock := newFakeTimeout(gotimeout.WithOnTrigger(func(_ time.Duration, count int) {
+		triggers.Add(1)
+		triggered.Add(int64(count))
+	}))
+	const n = 1000
+	var fired atomic.Int64
+	//the goroutines that lose the race to create the entry join the one that won
+	scheduleAtOnce(n, func() { to.AfterFunc(1, func() { fired.Add(1) }) })
+	if s := to.Stats(); s.CacheMisses != 1 || s.CacheHits != n-1 || s.ActiveEntries != 1 {
+		t.Fatalf("Stats %+v, want a single entry joined by the other %d callbacks", s, n-1)
+	}
+	clock.Advance(time.Second)
+	if triggers.Load() != 1 || triggered.Load() != n || fired.Load() != n {
+		t.Fatalf("%d entries fired %d callbacks, %d ran, want 1 entry firing all %d", triggers.Load(), triggered.Load(), fired.Load(), n)
+	}
+}
+
+func TestSetMaxSecondsWhileScheduling(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10))
+	const goroutines, n = 8, 500
+	var fired atomic.Int64
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	go func() {
+		//grow and shrink across the lengths being scheduled until the schedulers are done
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			to.SetMaxSeconds([]int{5, 30, 15, 1}[i%4])
+		}
+	}()
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				seconds := 1 + (g*n+i)%30
+				to.AfterFunc(seconds, func() { fired.Add(1) })
+				to.AfterFuncNotBefore(time.Duration(seconds)*time.Second, func() { fired.Add(1) })
+				to.Pending(seconds)
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(done)
+
+	clock.Advance(time.Minute)
+	if got := fired.Load(); got != 2*goroutines*n {
+		t.Fatalf("%d of %d callbacks fired", got, 2*goroutines*n)
+	}
+	if s := to.Stats(); s.Pending != 0 || s.ActiveEntries != 0 {
+		t.Fatalf("Stats %+v after everything fired", s)
+	}
+}
+
+func TestSetMaxSeconds(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10))
+	fired := 0
+	to.AfterFunc(8, func() { fired++ })
+	to.SetMaxSeconds(5)
+	//8 is beyond the cached range now, the entry it had still fires
+	if got := to.Pending(8); got != 0 {
+		t.Fatalf("Pending(8) = %d beyond the shrunk range", got)
+	}
+	to.SetMaxSeconds(20)
+	to.AfterFunc(15, func() { fired++ })
+	if got := to.Pending(15); got != 1 {
+		t.Fatalf("Pending(15) = %d after growing the range", got)
+	}
+	clock.Advance(20 * time.Second)
+	if fired != 2 {
+		t.Fatalf("%d of 2 callbacks fired", fired)
+	}
+}
+
+func TestScheduleFromCallback(t *testing.T) {
+	to, clock := newFakeTimeout()
+	start := clock.Now()
+	var inner atomic.Int64
+	var firedAt time.Time
+	to.AfterFunc(1, func() {
+		//same length as the entry that is firing, it must go into a new entry due a second from now
+		to.AfterFunc(1, func() {
+			inner.Add(1)
+			firedAt = clock.Now()
+		})
+	})
+	clock.Advance(time.Second)
+	if inner.Load() != 0 {
+		t.Fatal("the callback scheduled from a firing callback ran with it")
+	}
+	clock.Advance(time.Second - time.Millisecond)
+	if inner.Load() != 0 {
+		t.Fatal("the callback scheduled from a firing callback ran early")
+	}
+	clock.Advance(time.Millisecond)
+	if inner.Load() != 1 {
+		t.Fatalf("the callback scheduled from a firing callback ran %d times, want 1", inner.Load())
+	}
+	if got := firedAt.Sub(start); got != 2*time.Second {
+		t.Fatalf("fired after %v, want 2s", got)
+	}
+}
+
+func TestLIFOOrder(t *testing.T) {
+	for _, workers := range []int{0, 1} {
+		to, clock := newFakeTimeout(gotimeout.WithExecutionOrder(gotimeout.LIFO), gotimeout.WithWorkers(workers))
+		var mu sync.Mutex
+		var order []int
+		for i := 0; i < 50; i++ {
+			i := i
+			to.AfterFunc(1, func() {
+				mu.Lock()
+				order = append(order, i)
+				mu.Unlock()
+			})
+		}
+		clock.Advance(time.Second)
+		//a single worker takes the callbacks one by one in the order they were handed to the pool
+		to.Wait()
+		if len(order) != 50 {
+			t.Fatalf("workers %d: %d of 50 callbacks ran", workers, len(order))
+		}
+		for i := range order {
+			if order[i] != 49-i {
+				t.Fatalf("workers %d: callbacks ran in order %v, want the last scheduled first", workers, order)
+			}
+		}
+	}
+}
+
+func TestResetWhileArmed(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10))
+	var stale atomic.Int64
+	for i := 0; i < 100; i++ {
+		to.AfterFunc(1, func() { stale.Add(1) })
+		to.AfterDuration(30*time.Millisecond, func() { stale.Add(1) })
+		to.AfterFunc(700, func() { stale.Add(1) })
+		to.AfterFuncPrecise(time.Second, func() { stale.Add(1) })
+	}
+	to.Reset()
+	if s := to.Stats(); s != (gotimeout.Stats{}) {
+		t.Fatalf("Stats %+v after Reset, want all zero", s)
+	}
+
+	//the Timeout stays usable, and nothing from before the Reset fires against it
+	var fresh atomic.Int64
+	to.AfterFunc(1, func() { fresh.Add(1) })
+	clock.Advance(time.Hour)
+	if stale.Load() != 0 {
+		t.Fatalf("%d callbacks scheduled before Reset fired", stale.Load())
+	}
+	if fresh.Load() != 1 {
+		t.Fatal("a callback scheduled after Reset did not fire")
+	}
+	if s := to.Stats(); s.CacheMisses != 1 || s.Fired != 1 || s.ActiveEntries != 0 {
+		t.Fatalf("Stats %+v, want only the callback scheduled after Reset", s)
+	}
+}
+
+func TestResetWhileScheduling(t *testing.T) {
+	to, clock := newFakeTimeout()
+	stop := advancing(clock)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				to.AfterDuration(time.Duration(1+i%20)*time.Millisecond*10, func() {})
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		to.Reset()
+	}
+	wg.Wait()
+	stop()
+	//whatever survived the resets fires, and the gauges come back to zero
+	clock.Advance(time.Hour)
+	to.Wait()
+	if s := to.Stats(); s.ActiveEntries != 0 || s.Pending != 0 {
+		t.Fatalf("Stats %+v once everything fired", s)
+	}
+}
+
+func TestNotBeforeNeverEarly(t *testing.T) {
+	to, clock := newFakeTimeout()
+	start := clock.Now()
+	var early, fired atomic.Int64
+	scheduled := 0
+	//scheduling 37ms apart joins entries at every age within the cache window, plain AfterDuration would fire early
+	for i := 0; i < 40; i++ {
+		for _, d := range []time.Duration{50 * time.Millisecond, 250 * time.Millisecond, time.Second, 3 * time.Second} {
+			at := clock.Now()
+			d := d
+			to.AfterFuncNotBefore(d, func() {
+				fired.Add(1)
+				if clock.Now().Sub(at) < d {
+					early.Add(1)
+				}
+			})
+			scheduled++
+		}
+		clock.Advance(37 * time.Millisecond)
+	}
+	clock.Set(start.Add(time.Minute))
+	if got := fired.Load(); got != int64(scheduled) {
+		t.Fatalf("%d of %d callbacks fired", got, scheduled)
+	}
+	if got := early.Load(); got != 0 {
+		t.Fatalf("%d callbacks fired before their timeout", got)
+	}
+}
+
+func TestMaxLongEntries(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10), gotimeout.WithMaxLongEntries(2))
+	fired := map[int]int{}
+	schedule := func(seconds int) {
+		to.AfterFunc(seconds, func() { fired[seconds]++ })
+	}
+	schedule(20)
+	schedule(30)
+	//joining 20 makes 30 the least recently used length, which 40 evicts
+	schedule(20)
+	schedule(40)
+	if s := to.Stats(); s.LongEvictions != 1 || s.CacheMisses != 3 {
+		t.Fatalf("Stats %+v, want 3 entries and 1 eviction", s)
+	}
+	//the evicted length starts a fresh entry, the evicted one still fires its callback
+	schedule(30)
+	if s := to.Stats(); s.LongEvictions != 2 || s.CacheMisses != 4 || s.ActiveEntries != 4 {
+		t.Fatalf("Stats %+v, want 4 armed entries and 2 evictions", s)
+	}
+
+	clock.Advance(30 * time.Second)
+	if fired[20] != 2 || fired[30] != 2 || fired[40] != 0 {
+		t.Fatalf("fired %v after 30s", fired)
+	}
+	clock.Advance(10 * time.Second)
+	if fired[40] != 1 {
+		t.Fatalf("fired %v after 40s", fired)
+	}
+}
+
+func TestAfterFuncResult(t *testing.T) {
+	var errs []error
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10), gotimeout.WithFallback(gotimeout.FallbackUnique),
+		gotimeout.WithErrorHandler(func(err error) { errs = append(errs, err) }))
+	fired := 0
+	cb := func() { fired++ }
+	if got := to.AfterFuncResult(5, cb); got != gotimeout.ScheduleCreated {
+		t.Fatalf("first schedule = %v, want ScheduleCreated", got)
+	}
+	if got := to.AfterFuncResult(5, cb); got != gotimeout.ScheduleJoined {
+		t.Fatalf("second schedule = %v, want ScheduleJoined", got)
+	}
+	if got := to.AfterFuncResult(20, cb); got != gotimeout.ScheduleUnique {
+		t.Fatalf("schedule beyond MaxSeconds = %v, want ScheduleUnique", got)
+	}
+	if s := to.Stats(); s.CacheHits != 1 {
+		t.Fatalf("Stats %+v, want the join counted as a hit", s)
+	}
+	clock.Advance(20 * time.Second)
+	if fired != 3 {
+		t.Fatalf("%d callbacks fired, want 3", fired)
+	}
+
+	done := make(chan struct{})
+	if got := to.AfterFuncResult(0, func() { close(done) }); got != gotimeout.ScheduleImmediate {
+		t.Fatalf("zero timeout = %v, want ScheduleImmediate", got)
+	}
+	waitFor(t, done, "zero timeout")
+	to.Stop()
+	if got := to.AfterFuncResult(5, cb); got != gotimeout.ScheduleRejected || len(errs) != 1 {
+		t.Fatalf("schedule after Stop = %v with errors %v, want ScheduleRejected reported once", got, errs)
+	}
+}
+
+func TestCoalesce(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithCoalesce(1))
+	start := clock.Now()
+	var firedAt []time.Duration
+	record := func() { firedAt = append(firedAt, clock.Now().Sub(start)) }
+	to.AfterFunc(5, record)
+	//4 and 6 join the entry of 5, 7 is too far from it and gets its own
+	to.AfterFunc(4, record)
+	to.AfterFunc(6, record)
+	to.AfterFunc(7, record)
+	//21 is a second away from both 20 and 22, it joins the longer one
+	to.AfterFunc(20, record)
+	to.AfterFunc(22, record)
+	to.AfterFunc(21, record)
+	if s := to.Stats(); s.CacheMisses != 4 || s.CacheHits != 3 {
+		t.Fatalf("Stats %+v, want 4 entries joined 3 times", s)
+	}
+	clock.Advance(time.Minute)
+	want := []time.Duration{5 * time.Second, 5 * time.Second, 5 * time.Second, 7 * time.Second, 20 * time.Second, 22 * time.Second, 22 * time.Second}
+	if !slices.Equal(firedAt, want) {
+		t.Fatalf("fired at %v, want %v", firedAt, want)
+	}
+	if earliest, latest := to.EffectiveWindow(5); earliest != 3500*time.Millisecond || latest != 6*time.Second {
+		t.Fatalf("EffectiveWindow(5) = %v, %v", earliest, latest)
+	}
+}
+
+func TestMaxCallbacksPerBucket(t *testing.T) {
+	var triggered []int
+	to, clock := newFakeTimeout(gotimeout.WithMaxCallbacksPerBucket(3),
+		gotimeout.WithOnTrigger(func(_ time.Duration, count int) { triggered = append(triggered, count) }))
+	fired := 0
+	for i := 0; i < 7; i++ {
+		to.AfterFunc(1, func() { fired++ })
+	}
+	//the 4th and 7th callback found their entry full and started the next one
+	if s := to.Stats(); s.Overflows != 2 || s.ActiveEntries != 3 || s.Pending != 7 {
+		t.Fatalf("Stats %+v, want 3 entries after 2 overflows", s)
+	}
+	clock.Advance(time.Second)
+	if fired != 7 || !slices.Equal(triggered, []int{3, 3, 1}) {
+		t.Fatalf("%d callbacks fired in batches of %v, want 7 in batches of at most 3", fired, triggered)
+	}
+
+	//an empty entry takes a larger batch, the next callback spills
+	triggered = nil
+	to.AfterFuncBatch(2, []gotimeout.TimeoutCallback{func() {}, func() {}, func() {}, func() {}})
+	to.AfterFunc(2, func() {})
+	clock.Advance(2 * time.Second)
+	if !slices.Equal(triggered, []int{4, 1}) {
+		t.Fatalf("batches of %v, want the whole batch and the spilled callback apart", triggered)
+	}
+}
+
+func TestAtNextBoundary(t *testing.T) {
+	clock := gotimeouttest.NewFakeClock(time.Date(2026, 3, 1, 12, 0, 20, 0, time.UTC))
+	to := gotimeout.MustNewTimeout(gotimeout.WithClock(clock))
+	var firedAt time.Time
+	to.AtNextBoundary(time.Minute, func() { firedAt = clock.Now() })
+	clock.Advance(time.Minute)
+	if want := time.Date(2026, 3, 1, 12, 1, 0, 0, time.UTC); !firedAt.Equal(want) {
+		t.Fatalf("fired at %v, want %v", firedAt, want)
+	}
+}
+
+func TestCancelRacesFire(t *testing.T) {
+	for _, opts := range [][]gotimeout.Option{nil, {gotimeout.WithWorkers(4)}} {
+		for round := 0; round < 20; round++ {
+			to, clock := newFakeTimeout(opts...)
+			const n = 500
+			var ran [n]atomic.Bool
+			refs := make([]gotimeout.CallbackRef, n)
+			for i := range refs {
+				i := i
+				refs[i] = to.AfterFuncRef(1, func() { ran[i].Store(true) })
+			}
+			var cancelled [n]bool
+			var wg sync.WaitGroup
+			for g := 0; g < 4; g++ {
+				wg.Add(1)
+				go func(g int) {
+					defer wg.Done()
+					for i := g; i < n; i += 4 {
+						cancelled[i] = to.Cancel(refs[i])
+					}
+				}(g)
+			}
+			clock.Advance(time.Second)
+			wg.Wait()
+			to.Wait()
+			for i := range refs {
+				if cancelled[i] == ran[i].Load() {
+					t.Fatalf("round %d: callback %d cancelled %v and ran %v, want exactly one", round, i, cancelled[i], ran[i].Load())
+				}
+			}
+		}
+	}
+}
+
+func TestCancelAll(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10))
+	fired := map[int]int{}
+	schedule := func(seconds int) {
+		to.AfterFunc(seconds, func() { fired[seconds]++ })
+	}
+	schedule(5)
+	schedule(5)
+	schedule(20)
+	//past the cache window 5 gets its second entry, both are cancelled
+	clock.Advance(600 * time.Millisecond)
+	schedule(5)
+	schedule(6)
+	schedule(20)
+	to.AfterFuncPrecise(5*time.Second, func() { fired[-5]++ })
+
+	if got := to.CancelAll(5); got != 3 {
+		t.Fatalf("CancelAll(5) = %d, want 3", got)
+	}
+	if got := to.CancelAll(20); got != 2 {
+		t.Fatalf("CancelAll(20) = %d, want 2", got)
+	}
+	if got := to.CancelAll(7); got != 0 {
+		t.Fatalf("CancelAll(7) = %d for a length nothing waits for", got)
+	}
+	clock.Advance(time.Minute)
+	if fired[5] != 0 || fired[20] != 0 || fired[6] != 1 || fired[-5] != 1 {
+		t.Fatalf("fired %v, want only 6 and the precise timer", fired)
+	}
+	if s := to.Stats(); s.ActiveEntries != 0 || s.Pending != 0 {
+		t.Fatalf("Stats %+v after everything fired or was cancelled", s)
+	}
+}
+
+func TestCancelAllWhileScheduling(t *testing.T) {
+	to, clock := newFakeTimeout()
+	const goroutines, perGoroutine = 4, 500
+	var fired, cancelled atomic.Int64
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				to.AfterFunc(1, func() { fired.Add(1) })
+			}
+		}()
+	}
+	//callbacks racing a CancelAll are either cancelled or go into a fresh entry, never lost
+	for i := 0; i < 50; i++ {
+		cancelled.Add(int64(to.CancelAll(1)))
+	}
+	wg.Wait()
+	clock.Advance(time.Second)
+	if got := fired.Load() + cancelled.Load(); got != goroutines*perGoroutine {
+		t.Fatalf("%d fired and %d cancelled, want %d in total", fired.Load(), cancelled.Load(), goroutines*perGoroutine)
+	}
+}
+
+func TestEntriesRunApart(t *testing.T) {
+	to := gotimeout.MustNewTimeout(gotimeout.WithGranularity(10*time.Millisecond), gotimeout.WithErrorHandler(func(error) {}))
+	defer to.Stop()
+	release := make(chan struct{})
+	defer close(release)
+	//the first entry blocks and panics on the goroutine it was started on, the second still fires on time
+	to.AfterDuration(20*time.Millisecond, func() { <-release })
+	to.AfterDuration(20*time.Millisecond, func() { panic("boom") })
+	fired := make(chan struct{})
+	to.AfterDuration(60*time.Millisecond, func() { close(fired) })
+	waitFor(t, fired, "entry fired after a blocked one")
+}
+
+//benchmarkFire fires a unique entry per op through a TimerFunc that calls fire with the func of the timer
+func benchmarkFire(b *testing.B, fire func(f func())) {
+	var armed func()
+	to := gotimeout.MustNewTimeout(gotimeout.WithTimerFunc(func(_ time.Duration, f func()) func() {
+		armed = f
+		return func() {}
+	}))
+	var wg sync.WaitGroup
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		to.AfterFuncPrecise(time.Second, wg.Done)
+		fire(armed)
+		wg.Wait()
+	}
+}
+
+//BenchmarkFireOnCaller and BenchmarkFireOnGoroutine show what starting a goroutine per fired entry costs
+func BenchmarkFireOnCaller(b *testing.B) {
+	benchmarkFire(b, func(f func()) { f() })
+}
+
+func BenchmarkFireOnGoroutine(b *testing.B) {
+	benchmarkFire(b, func(f func()) { go f() })
+}
+
+func TestOnCreate(t *testing.T) {
+	var created []time.Duration
+	to, clock := newFakeTimeout(gotimeout.WithCacheWindow(time.Second), gotimeout.WithOnCreate(func(timeout time.Duration) {
+		created = append(created, timeout)
+	}))
+	to.Prewarm(2)
+	to.AfterFunc(2, func() {})
+	to.AfterFunc(3, func() {})
+	to.AfterFunc(3, func() {})
+	if len(created) != 2 || created[0] != 2*time.Second || created[1] != 3*time.Second {
+		t.Fatalf("expected entries for 2s and 3s, got %v", created)
+	}
+	//once the cache window elapsed a new entry is created for the same length
+	clock.Advance(1500 * time.Millisecond)
+	to.AfterFunc(3, func() {})
+	if len(created) != 3 || to.Stats().CacheMisses != 3 {
+		t.Fatalf("expected a third entry, got %v", created)
+	}
+}
+
+func TestMeasureCallbacks(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMeasureCallbacks())
+	to.AfterFunc(1, func() { time.Sleep(20 * time.Millisecond) })
+	to.AfterFunc(1, func() {})
+	clock.Advance(2 * time.Second)
+	s := to.Stats()
+	if s.Measured != 2 || s.CallbackMax < 20*time.Millisecond || s.CallbackTotal < s.CallbackMax {
+		t.Fatalf("unexpected measurements %+v", s)
+	}
+
+	unmeasured, clock := newFakeTimeout()
+	unmeasured.AfterFunc(1, func() {})
+	clock.Advance(2 * time.Second)
+	if s := unmeasured.Stats(); s.Measured != 0 || s.CallbackTotal != 0 {
+		t.Fatalf("expected no measurements without MeasureCallbacks, got %+v", s)
+	}
+}
+
+func TestTimersSaved(t *testing.T) {
+	to, clock := newFakeTimeout()
+	for i := 0; i < 10; i++ {
+		to.AfterFunc(5, func() {})
+	}
+	for i := 0; i < 5; i++ {
+		to.AfterFunc(3, func() {})
+	}
+	to.AfterFuncBatch(7, []gotimeout.TimeoutCallback{func() {}, func() {}, func() {}, func() {}})
+	//unique timers save nothing
+	to.AfterFuncPrecise(time.Second, func() {})
+	to.AfterFuncPrecise(time.Second, func() {})
+	s := to.Stats()
+	//one timer per length, the other callbacks joined it
+	if s.TimersSaved != 9+4+3 || s.UniqueTimers != 2 || s.CacheMisses != 3 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+	//an entry that fired is not joined, the next callback arms a new timer
+	clock.Advance(10 * time.Second)
+	to.AfterFunc(5, func() {})
+	if got := to.Stats().TimersSaved; got != 16 {
+		t.Fatalf("expected TimersSaved to stay at 16, got %d", got)
+	}
+}
+
+func TestMaxEntryAge(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithCacheWindow(10*time.Second), gotimeout.WithMaxEntryAge(2*time.Second))
+	to.AfterFunc(30, func() {})
+	clock.Advance(time.Second)
+	to.AfterFunc(30, func() {})
+	if got := to.Stats().CacheMisses; got != 1 {
+		t.Fatalf("expected the entry to be joined within MaxEntryAge, got %d entries", got)
+	}
+	//well within the cache window, but past MaxEntryAge the entry is recreated
+	clock.Advance(1500 * time.Millisecond)
+	to.AfterFunc(30, func() {})
+	if s := to.Stats(); s.CacheMisses != 2 || s.ActiveEntries != 2 {
+		t.Fatalf("expected a fresh entry past MaxEntryAge, got %+v", s)
+	}
+	//nothing is dropped, both entries fire
+	clock.Advance(time.Minute)
+	if got := to.Stats().Fired; got != 3 {
+		t.Fatalf("expected 3 callbacks to fire, got %d", got)
+	}
+}
+
+func TestOnEmpty(t *testing.T) {
+	var emptied []time.Duration
+	to, clock := newFakeTimeout(gotimeout.WithOnEmpty(func(timeout time.Duration) { emptied = append(emptied, timeout) }))
+	first := to.AfterFuncCancellable(3, func() {})
+	second := to.AfterFuncCancellable(3, func() {})
+	first()
+	if len(emptied) != 0 {
+		t.Fatal("OnEmpty called while a callback was still waiting")
+	}
+	second()
+	if len(emptied) != 1 || emptied[0] != 3*time.Second {
+		t.Fatalf("expected the 3s entry to be emptied, got %v", emptied)
+	}
+	//the emptied entry stopped its timer and is no longer joined
+	if s := to.Stats(); s.ActiveEntries != 0 || s.Pending != 0 {
+		t.Fatalf("expected the emptied entry to be dropped, got %+v", s)
+	}
+	fired := false
+	to.AfterFunc(3, func() { fired = true })
+	if got := to.Stats().CacheMisses; got != 2 {
+		t.Fatalf("expected a fresh entry after OnEmpty, got %d entries", got)
+	}
+	//a cancel after the entry fired does not empty it
+	late := to.AfterFuncCancellable(3, func() {})
+	clock.Advance(4 * time.Second)
+	late()
+	if !fired || len(emptied) != 1 {
+		t.Fatalf("expected the fresh entry to fire without OnEmpty, fired %v, emptied %v", fired, emptied)
+	}
+
+	//CancelAll, Extract and a CancelToken empty entries too
+	emptied = nil
+	to.AfterFunc(1, func() {})
+	to.CancelAll(1)
+	to.AfterFunc(2, func() {})
+	to.Extract()
+	token := gotimeout.NewCancelToken()
+	to.AfterFuncToken(4, token, func() {})
+	token.Cancel()
+	if !slices.Equal(emptied, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}) {
+		t.Fatalf("expected CancelAll, Extract and the token to empty their entries, got %v", emptied)
+	}
+	//an entry without callbacks is not emptied by them
+	to.Prewarm(5)
+	to.CancelAll(5)
+	if len(emptied) != 3 {
+		t.Fatalf("expected no OnEmpty for an entry without callbacks, got %v", emptied)
+	}
+	if s := to.Stats(); s.ActiveEntries != 0 || s.Pending != 0 {
+		t.Fatalf("expected the emptied entries to be dropped, got %+v", s)
+	}
+}
+
+func TestAfterFuncContext(t *testing.T) {
+	to, clock := newFakeTimeout()
+	ctx, cancel := context.WithCancel(context.Background())
+	var cancelled, other, live atomic.Bool
+	to.AfterFuncContext(ctx, 2, func() { cancelled.Store(true) })
+	to.AfterFunc(2, func() { other.Store(true) })
+	to.AfterFuncContext(context.Background(), 2, func() { live.Store(true) })
+	cancel()
+	clock.Advance(2 * time.Second)
+	if cancelled.Load() {
+		t.Fatal("a callback whose context was cancelled ran")
+	}
+	if !other.Load() || !live.Load() {
+		t.Fatalf("expected the other callbacks of the entry to run, ran %v and %v", other.Load(), live.Load())
+	}
+	//a context that is done already schedules nothing
+	to.AfterFuncContext(ctx, 1, func() { cancelled.Store(true) })
+	if got := to.Stats().Pending; got != 0 {
+		t.Fatalf("expected nothing to be scheduled for a done context, %d pending", got)
+	}
+	clock.Advance(time.Second)
+	if cancelled.Load() {
+		t.Fatal("a callback scheduled with a done context ran")
+	}
+}
+
+func TestPanicHandler(t *testing.T) {
+	var recovered []interface{}
+	defer func(previous func(interface{})) { gotimeout.PanicHandler = previous }(gotimeout.PanicHandler)
+	gotimeout.PanicHandler = func(r interface{}) { recovered = append(recovered, r) }
+	to, clock := newFakeTimeout()
+	ran := 0
+	to.AfterFunc(1, func() { ran++ })
+	to.AfterFunc(1, func() { panic("boom") })
+	to.AfterFunc(1, func() { ran++ })
+	clock.Advance(time.Second)
+	if len(recovered) != 1 || recovered[0] != "boom" {
+		t.Fatalf("expected PanicHandler to get the panic, got %v", recovered)
+	}
+	//a panicking callback does not keep the rest of its entry from running
+	if ran != 2 {
+		t.Fatalf("expected both other callbacks to run, %d did", ran)
+	}
+}
+
+func TestTimingWheel(t *testing.T) {
+	//the wheel runs on a real ticker, a 1ms tick cascades the 200ms timeout down from the second level
+	to := gotimeout.MustNewTimeout(gotimeout.WithTimingWheel(time.Millisecond))
+	defer to.Stop()
+	start := time.Now()
+	short, long := make(chan time.Duration, 1), make(chan time.Duration, 1)
+	to.AfterDuration(20*time.Millisecond, func() { short <- time.Since(start) })
+	to.AfterDuration(200*time.Millisecond, func() { long <- time.Since(start) })
+	//a stopped timer of the wheel is skipped once the wheel reaches it, before the 200ms one fires
+	cancelled := to.AfterFuncPrecise(100*time.Millisecond, func() { t.Error("a cancelled callback fired through the wheel") })
+	cancelled()
+	for _, tc := range []struct {
+		fired <-chan time.Duration
+		want  time.Duration
+	}{{short, 20 * time.Millisecond}, {long, 200 * time.Millisecond}} {
+		select {
+		case got := <-tc.fired:
+			//durations round to a 100ms bucket, the 20ms one gets a unique timer of the wheel
+			if got < tc.want {
+				t.Fatalf("the %v timeout fired early, after %v", tc.want, got)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("the %v timeout did not fire", tc.want)
+		}
+	}
+	if got := to.Stats().UniqueTimers; got != 2 {
+		t.Fatalf("expected the 20ms and the precise timeout to get a unique timer each, got %d", got)
+	}
+}
+
+func TestAfterFuncAt(t *testing.T) {
+	to, clock := newFakeTimeout()
+	start := clock.Now()
+	var scheduled, actual []time.Time
+	record := func(s, a time.Time) {
+		scheduled = append(scheduled, s)
+		actual = append(actual, a)
+	}
+	to.AfterFuncAt(2, record)
+	//joins the same entry 400ms later, so it is scheduled for the entry's deadline rather than its own
+	clock.Advance(400 * time.Millisecond)
+	to.AfterFuncAt(2, record)
+	clock.Advance(2 * time.Second)
+	deadline := start.Add(2 * time.Second)
+	if len(scheduled) != 2 || !scheduled[0].Equal(deadline) || !scheduled[1].Equal(deadline) {
+		t.Fatalf("expected both callbacks to be scheduled for %v, got %v", deadline, scheduled)
+	}
+	if !actual[0].Equal(deadline) || !actual[1].Equal(deadline) {
+		t.Fatalf("expected both callbacks to fire at %v, got %v", deadline, actual)
+	}
+}
+
+func TestAtFunc(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var fired atomic.Bool
+	to.AtFunc(clock.Now().Add(1500*time.Millisecond), func() { fired.Store(true) })
+	clock.Advance(1400 * time.Millisecond)
+	if fired.Load() {
+		t.Fatal("AtFunc fired before its deadline")
+	}
+	clock.Advance(100 * time.Millisecond)
+	if !fired.Load() {
+		t.Fatal("AtFunc did not fire at its deadline")
+	}
+	//a deadline in the past runs right away, on its own goroutine
+	late := make(chan struct{})
+	to.AtFunc(clock.Now().Add(-time.Hour), func() { close(late) })
+	waitFor(t, late, "callback for a past deadline")
+}
+
+func TestAfterFuncRetry(t *testing.T) {
+	to, clock := newFakeTimeout()
+	failing := errors.New("not yet")
+	succeeding, exhausted := 0, 0
+	to.AfterFuncRetry(1, 5, func() error {
+		succeeding++
+		if succeeding < 3 {
+			return failing
+		}
+		return nil
+	})
+	to.AfterFuncRetry(1, 2, func() error {
+		exhausted++
+		return failing
+	})
+	clock.Advance(time.Second)
+	if succeeding != 1 || exhausted != 1 {
+		t.Fatalf("expected one run each after the first second, got %d and %d", succeeding, exhausted)
+	}
+	//every retry is scheduled anew for the same timeout
+	clock.Advance(10 * time.Second)
+	if succeeding != 3 {
+		t.Fatalf("expected the callback to stop retrying once it succeeded, ran %d times", succeeding)
+	}
+	if exhausted != 3 {
+		t.Fatalf("expected the failing callback to run attempts+1 times, ran %d times", exhausted)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithMaxSeconds(10), gotimeout.WithFallback(gotimeout.FallbackUnique))
+	to.Reserve(2, 100)
+	if s := to.Stats(); s.ActiveEntries != 1 || s.CacheMisses != 1 {
+		t.Fatalf("expected Reserve to create the entry, got %+v", s)
+	}
+	fired := 0
+	for i := 0; i < 100; i++ {
+		to.AfterFunc(2, func() { fired++ })
+	}
+	if s := to.Stats(); s.ActiveEntries != 1 || s.CacheHits != 100 {
+		t.Fatalf("expected every callback to join the reserved entry, got %+v", s)
+	}
+	clock.Advance(2 * time.Second)
+	if fired != 100 {
+		t.Fatalf("expected 100 callbacks to fire, got %d", fired)
+	}
+	//a length with a unique timer per callback has no entry to reserve, nor has a stopped Timeout
+	to.Reserve(20, 10)
+	to.Stop()
+	to.Reserve(3, 10)
+	if s := to.Stats(); s.ActiveEntries != 0 || s.CacheMisses != 1 {
+		t.Fatalf("expected no entry to be created, got %+v", s)
+	}
+}
+
+func TestMaxPendingCallbacks(t *testing.T) {
+	var reported []error
+	to, clock := newFakeTimeout(gotimeout.WithMaxPendingCallbacks(2),
+		gotimeout.WithErrorHandler(func(err error) { reported = append(reported, err) }))
+	fired := 0
+	for i := 0; i < 2; i++ {
+		if err := to.TryAfterFunc(1, func() { fired++ }); err != nil {
+			t.Fatalf("callback %d within the limit: %v", i, err)
+		}
+	}
+	if err := to.TryAfterFunc(1, func() { fired++ }); !errors.Is(err, gotimeout.ErrTooManyPending) {
+		t.Fatalf("expected ErrTooManyPending beyond the limit, got %v", err)
+	}
+	to.AfterFunc(1, func() { fired++ })
+	to.AfterFuncBatch(1, []gotimeout.TimeoutCallback{func() { fired++ }})
+	if len(reported) != 2 || !errors.Is(reported[0], gotimeout.ErrTooManyPending) || !errors.Is(reported[1], gotimeout.ErrTooManyPending) {
+		t.Fatalf("expected the dropped callbacks to be reported, got %v", reported)
+	}
+	clock.Advance(time.Second)
+	if fired != 2 {
+		t.Fatalf("expected only the callbacks within the limit to fire, %d did", fired)
+	}
+	//fired callbacks make room again
+	if err := to.TryAfterFunc(1, func() {}); err != nil {
+		t.Fatalf("expected room once the callbacks fired, got %v", err)
+	}
+}
+
+func TestCallbackTimeout(t *testing.T) {
+	reported := make(chan error, 1)
+	to, clock := newFakeTimeout(gotimeout.WithCallbackTimeout(time.Second),
+		gotimeout.WithErrorHandler(func(err error) { reported <- err }))
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	to.AfterFunc(1, func() {
+		close(started)
+		<-release
+	})
+	next := make(chan struct{})
+	to.AfterFunc(1, func() { close(next) })
+	//the entry fires on its own goroutine here, so the clock can move on while the first callback blocks
+	go clock.Advance(time.Second)
+	waitFor(t, started, "blocking callback")
+	select {
+	case <-next:
+		t.Fatal("the entry moved on before the callback overran its timeout")
+	default:
+	}
+	//the callback may start before its timeout is armed, the clock moves on until the timeout is due
+	var err error
+	for tries := 0; err == nil; tries++ {
+		if tries == 100 {
+			t.Fatal("the overrunning callback was not reported")
+		}
+		clock.Advance(time.Second)
+		select {
+		case err = <-reported:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if !errors.Is(err, gotimeout.ErrCallbackTimeout) {
+		t.Fatalf("expected ErrCallbackTimeout, got %v", err)
+	}
+	//the abandoned callback keeps running, the rest of the entry does not wait for it
+	waitFor(t, next, "callback after the abandoned one")
+}
+
+func TestNewTimer(t *testing.T) {
+	to, clock := newFakeTimeout()
+	fired := to.NewTimer(1)
+	stopped := to.NewTimer(1)
+	if !stopped.Stop() {
+		t.Fatal("expected Stop to stop a pending timer")
+	}
+	if stopped.Stop() {
+		t.Fatal("expected a second Stop to return false")
+	}
+	clock.Advance(time.Second)
+	select {
+	case at := <-fired.C:
+		if !at.Equal(clock.Now()) {
+			t.Fatalf("expected the timer to receive the time it fired at, got %v", at)
+		}
+	default:
+		t.Fatal("the timer did not fire")
+	}
+	if fired.Stop() {
+		t.Fatal("expected Stop to return false for a timer that fired")
+	}
+	select {
+	case <-stopped.C:
+		t.Fatal("a stopped timer fired")
+	default:
+	}
+}
+
+func TestAfterValue(t *testing.T) {
+	to, clock := newFakeTimeout()
+	answer := gotimeout.AfterValueOn(to, 1, func() int { return 42 })
+	panicked := gotimeout.AfterValueOn(to, 1, func() string { panic("boom") })
+	select {
+	case <-answer:
+		t.Fatal("AfterValue sent before the timeout")
+	default:
+	}
+	clock.Advance(time.Second)
+	select {
+	case got := <-answer:
+		if got != 42 {
+			t.Fatalf("expected 42, got %d", got)
+		}
+	default:
+		t.Fatal("AfterValue did not send the result")
+	}
+	//nothing is sent for a panicking fn
+	select {
+	case got := <-panicked:
+		t.Fatalf("expected nothing from a panicking fn, got %q", got)
+	default:
+	}
+}
+
+func TestSerialDispatch(t *testing.T) {
+	to, clock := newFakeTimeout(gotimeout.WithSerialDispatch())
+	release := make(chan struct{})
+	ran := make(chan int, 4)
+	to.AfterFunc(1, func() {
+		<-release
+		ran <- 1
+	})
+	to.AfterFunc(3, func() { ran <- 3 })
+	to.AfterFunc(2, func() { ran <- 2 })
+	to.AfterFunc(2, func() { ran <- 4 })
+	clock.Advance(3 * time.Second)
+	//every entry waits for the slow callback ahead of it, they all run on the one dispatcher goroutine
+	select {
+	case got := <-ran:
+		t.Fatalf("callback %d ran while the slow one was still running", got)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	var order []int
+	for len(order) < 4 {
+		select {
+		case got := <-ran:
+			order = append(order, got)
+		case <-time.After(time.Second):
+			t.Fatalf("only %v ran", order)
+		}
+	}
+	if !slices.Equal(order, []int{1, 2, 4, 3}) {
+		t.Fatalf("expected the entries to run by deadline, got %v", order)
+	}
+}
+
+func TestAfterFuncWindow(t *testing.T) {
+	var triggered []int
+	to, clock := newFakeTimeout(gotimeout.WithOnTrigger(func(_ time.Duration, count int) { triggered = append(triggered, count) }))
+	start := clock.Now()
+	to.AfterFunc(2, func() {})
+	clock.Advance(300 * time.Millisecond)
+	//the entry is too old for a 100ms window, a fresh one replaces it and later callbacks join that one
+	var narrow time.Time
+	to.AfterFuncWindow(2*time.Second, 100*time.Millisecond, func() { narrow = clock.Now() })
+	to.AfterFunc(2, func() {})
+	clock.Advance(1700 * time.Millisecond)
+	if !slices.Equal(triggered, []int{1}) {
+		t.Fatalf("expected the replaced entry to fire on time with its own callback, got batches of %v", triggered)
+	}
+	clock.Advance(300 * time.Millisecond)
+	if !slices.Equal(triggered, []int{1, 2}) || !narrow.Equal(start.Add(2300*time.Millisecond)) {
+		t.Fatalf("expected the fresh entry to fire 2s after the narrow callback, got batches of %v at %v", triggered, narrow.Sub(start))
+	}
+	//a window of zero never joins an entry
+	to.AfterFuncWindow(time.Second, 0, func() {})
+	if got := to.Stats().UniqueTimers; got != 1 {
+		t.Fatalf("expected a zero window to get a unique timer, got %d", got)
+	}
+}
+
+func TestAfterFuncGroup(t *testing.T) {
+	to, clock := newFakeTimeout()
+	var ran []int
+	group := to.AfterFuncGroup(1)
+	group.Add(func() { ran = append(ran, 1) })
+	group.Add(func() { ran = append(ran, 2) })
+	clock.Advance(time.Second)
+	if len(ran) != 0 {
+		t.Fatal("a Group that was not committed ran")
+	}
+	group.Commit()
+	group.Add(func() { ran = append(ran, 3) })
+	clock.Advance(time.Second)
+	if !slices.Equal(ran, []int{1, 2}) {
+		t.Fatalf("expected the committed callbacks to run together in order, got %v", ran)
+	}
+	if group.Abort() {
+		t.Fatal("expected Abort to return false for a Group that fired")
+	}
+
+	aborted := to.AfterFuncGroup(1)
+	aborted.Add(func() { ran = append(ran, 4) })
+	aborted.Commit()
+	if !aborted.Abort() || aborted.Abort() {
+		t.Fatal("expected only the first Abort to cancel the Group")
+	}
+	clock.Advance(time.Second)
+	if len(ran) != 2 {
+		t.Fatalf("an aborted Group ran, got %v", ran)
+	}
+}
@@ -0,0 +1,62 @@
+package gotimeout
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//timeoutContext is the context of WithTimeoutContext, it reports a deadline and DeadlineExceeded like the one of context.WithTimeout
+type timeoutContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *timeoutContext) Deadline() (time.Time, bool) {
+	if parent, ok := c.Context.Deadline(); ok && parent.Before(c.deadline) {
+		return parent, true
+	}
+	return c.deadline, true
+}
+
+func (c *timeoutContext) Err() error {
+	err := c.Context.Err()
+	if err != nil && errors.Is(context.Cause(c.Context), context.DeadlineExceeded) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+func WithTimeoutContext(parent context.Context, seconds int) (context.Context, context.CancelFunc) {
+	return defaultTimeout().WithTimeoutContext(parent, seconds)
+}
+
+// WithTimeoutContext works like context.WithTimeout, but the context expires when a cached timer for seconds fires
+// e.g. a server handling thousands of requests with the same timeout shares a timer per entry rather than arming one per request
+// once it fired Err returns context.DeadlineExceeded, Deadline reports the timeout, or the deadline of parent if that is earlier,
+// it may expire up to CacheWindow before that deadline, like any callback joining an entry late
+// the CancelFunc removes the callback from its entry, as does cancelling parent, so neither leaves it waiting until the entry fires
+// contexts derived from it get context.Canceled as their Err once it expired, with context.DeadlineExceeded as their Cause
+// if the timeout cannot be scheduled, e.g. after Stop, the context is cancelled right away with the error as its cause
+func (t *Timeout) WithTimeoutContext(parent context.Context, seconds int) (context.Context, context.CancelFunc) {
+	inner, cancel := context.WithCancelCause(parent)
+	ctx := &timeoutContext{Context: inner, deadline: t.now().Add(secondsToDuration(seconds))}
+	if inner.Err() != nil {
+		return ctx, func() { cancel(context.Canceled) }
+	}
+	remove, err := t.scheduleSlot(secondsToDuration(seconds), &callbackSlot{callback: func() {
+		cancel(context.DeadlineExceeded)
+	}})
+	if err != nil {
+		cancel(err)
+		return ctx, func() {}
+	}
+	//parent cancelled first, the callback leaves its entry right away
+	stop := context.AfterFunc(inner, remove)
+	return ctx, func() {
+		if stop() {
+			remove()
+		}
+		cancel(context.Canceled)
+	}
+}
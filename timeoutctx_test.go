@@ -0,0 +1,71 @@
+package gotimeout_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestWithTimeoutContext(t *testing.T) {
+	to, clock := newFakeTimeout()
+	ctx, cancel := to.WithTimeoutContext(context.Background(), 2)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || !deadline.Equal(clock.Now().Add(2*time.Second)) {
+		t.Fatalf("unexpected deadline %v", deadline)
+	}
+	clock.Advance(time.Second)
+	if ctx.Err() != nil {
+		t.Fatal("expired early")
+	}
+	clock.Advance(time.Second)
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", ctx.Err())
+	}
+}
+
+func TestWithTimeoutContextCancel(t *testing.T) {
+	to, _ := newFakeTimeout()
+	ctx, cancel := to.WithTimeoutContext(context.Background(), 5)
+	if got := to.Stats().Pending; got != 1 {
+		t.Fatalf("expected a pending callback, got %d", got)
+	}
+	cancel()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatalf("expected Canceled, got %v", ctx.Err())
+	}
+	if got := to.Stats().Pending; got != 0 {
+		t.Fatalf("expected cancel to remove the callback, %d pending", got)
+	}
+}
+
+func TestWithTimeoutContextParent(t *testing.T) {
+	to, _ := newFakeTimeout()
+	parent, cancelParent := context.WithCancel(context.Background())
+	ctx, cancel := to.WithTimeoutContext(parent, 5)
+	defer cancel()
+	cancelParent()
+	<-ctx.Done()
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		t.Fatalf("expected Canceled, got %v", ctx.Err())
+	}
+	//the bucket callback is removed on a goroutine of its own once the parent is done
+	deadline := time.Now().Add(time.Second)
+	for to.Stats().Pending != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the parent's cancel did not remove the callback")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	stopped := gotimeout.MustNewTimeout()
+	stopped.Stop()
+	ctx, cancel = stopped.WithTimeoutContext(context.Background(), 5)
+	defer cancel()
+	if !errors.Is(context.Cause(ctx), gotimeout.ErrStopped) {
+		t.Fatalf("expected the context of a stopped Timeout to be cancelled, got %v", context.Cause(ctx))
+	}
+}
@@ -0,0 +1,40 @@
+package gotimeout
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// CachedTimer works like a *time.Timer, but shares the cached timers used by AfterFunc
+type CachedTimer struct {
+	C <-chan time.Time //receives the time the entry fired, unless the timer was stopped
+
+	cancel CancelFunc
+	done   atomic.Bool //set by whichever comes first, firing or Stop
+}
+
+func NewTimer(seconds int) *CachedTimer {
+	return defaultTimeout().NewTimer(seconds)
+}
+
+// NewTimer works like time.NewTimer, the timer joins the entry for the timeout length rather than owning a timer
+func (t *Timeout) NewTimer(seconds int) *CachedTimer {
+	c := make(chan time.Time, 1)
+	ct := &CachedTimer{C: c}
+	ct.cancel = t.schedule(secondsToDuration(seconds), func() {
+		if ct.done.CompareAndSwap(false, true) {
+			c <- t.now()
+		}
+	})
+	return ct
+}
+
+// Stop removes the timer from its entry, it returns false if the timer already fired or was stopped, like time.Timer.Stop
+// as with time.Timer, a value sent right before Stop returned false may still be waiting in C
+func (ct *CachedTimer) Stop() bool {
+	if !ct.done.CompareAndSwap(false, true) {
+		return false
+	}
+	ct.cancel()
+	return true
+}
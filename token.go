@@ -0,0 +1,100 @@
+package gotimeout
+
+import "sync"
+
+// CancelToken cancels every callback scheduled with it at once, e.g. all the timeouts of a request, see AfterFuncToken
+// a token can be used for any number of callbacks, on any Timeout, until it is cancelled, the zero value is ready to use
+type CancelToken struct {
+	mu        sync.Mutex
+	cancelled bool
+	cancels   map[*callbackSlot]CancelFunc //callbacks that did not fire yet, the CancelFunc is nil while the callback is being scheduled
+}
+
+// NewCancelToken returns a token that is not cancelled yet
+func NewCancelToken() *CancelToken {
+	return &CancelToken{}
+}
+
+func AfterFuncToken(seconds int, token *CancelToken, callback TimeoutCallback) {
+	defaultTimeout().AfterFuncToken(seconds, token, callback)
+}
+
+// AfterFuncToken works like AfterFunc, but the callback is cancelled along with every other callback of the token by token.Cancel
+// a callback that fired is forgotten by the token, so a long lived token only holds the callbacks still waiting
+// scheduling with a token that was cancelled already schedules nothing, the callback never runs
+func (t *Timeout) AfterFuncToken(seconds int, token *CancelToken, callback TimeoutCallback) {
+	slot := t.userSlot(callback)
+	slot.callback = func() {
+		token.forget(slot)
+		callback()
+	}
+	if !token.register(slot) {
+		return
+	}
+	token.arm(slot, t.scheduleOrReport(secondsToDuration(seconds), slot))
+}
+
+// Cancel cancels every callback scheduled with the token that did not fire yet, and any scheduled with it later on
+// callbacks that already fired are not affected, it returns false if the token was cancelled already
+func (c *CancelToken) Cancel() bool {
+	c.mu.Lock()
+	if c.cancelled {
+		c.mu.Unlock()
+		return false
+	}
+	c.cancelled = true
+	cancels := c.cancels
+	c.cancels = nil
+	c.mu.Unlock()
+
+	for _, cancel := range cancels {
+		//a nil one is still being scheduled, arm cancels it once it is
+		if cancel != nil {
+			cancel()
+		}
+	}
+	return true
+}
+
+// Cancelled tells if Cancel was called
+func (c *CancelToken) Cancelled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cancelled
+}
+
+//register adds a callback about to be scheduled, false if the token is cancelled and it should not be
+func (c *CancelToken) register(slot *callbackSlot) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancelled {
+		return false
+	}
+	if c.cancels == nil {
+		c.cancels = make(map[*callbackSlot]CancelFunc)
+	}
+	c.cancels[slot] = nil
+	return true
+}
+
+//arm stores the CancelFunc of a scheduled callback, or calls it if the token was cancelled while it was being scheduled
+func (c *CancelToken) arm(slot *callbackSlot, cancel CancelFunc) {
+	c.mu.Lock()
+	if c.cancelled {
+		c.mu.Unlock()
+		cancel()
+		return
+	}
+	//a callback that fired already was forgotten, there is nothing left to cancel
+	if _, ok := c.cancels[slot]; ok {
+		c.cancels[slot] = cancel
+	}
+	c.mu.Unlock()
+}
+
+//forget drops a callback that fired
+func (c *CancelToken) forget(slot *callbackSlot) {
+	c.mu.Lock()
+	delete(c.cancels, slot)
+	c.mu.Unlock()
+}
@@ -0,0 +1,46 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestCancelToken(t *testing.T) {
+	to, clock := newFakeTimeout()
+	token := gotimeout.NewCancelToken()
+	var ran []int
+	to.AfterFuncToken(1, token, func() { ran = append(ran, 1) })
+	to.AfterFuncToken(3, token, func() { ran = append(ran, 3) })
+	to.AfterFuncToken(5, token, func() { ran = append(ran, 5) })
+	//the first one fired before the token was cancelled and is not affected
+	clock.Advance(2 * time.Second)
+	if !token.Cancel() || token.Cancel() || !token.Cancelled() {
+		t.Fatal("expected only the first Cancel to succeed")
+	}
+	//scheduling with a cancelled token schedules nothing
+	to.AfterFuncToken(1, token, func() { ran = append(ran, -1) })
+	clock.Advance(10 * time.Second)
+	if len(ran) != 1 || ran[0] != 1 {
+		t.Fatalf("expected only the first callback to run, ran %v", ran)
+	}
+	if s := to.Stats(); s.Pending != 0 {
+		t.Fatalf("expected no pending callbacks, got %d", s.Pending)
+	}
+}
+
+func TestCancelTokenAcrossTimeouts(t *testing.T) {
+	first, firstClock := newFakeTimeout()
+	second, secondClock := newFakeTimeout()
+	var token gotimeout.CancelToken
+	fired := 0
+	first.AfterFuncToken(1, &token, func() { fired++ })
+	second.AfterFuncToken(1, &token, func() { fired++ })
+	token.Cancel()
+	firstClock.Advance(2 * time.Second)
+	secondClock.Advance(2 * time.Second)
+	if fired != 0 {
+		t.Fatalf("expected both callbacks to be cancelled, %d fired", fired)
+	}
+}
@@ -0,0 +1,10 @@
+package gotimeout
+
+import "time"
+
+// Tracer is told about every entry that fires, the gotimeoutotel package implements it with OpenTelemetry spans
+// it lives behind an interface so gotimeout itself stays free of dependencies
+type Tracer interface {
+	// Fire is called before the callbacks of an entry run, end is called once all of them returned
+	Fire(timeout time.Duration, callbacks int) (end func())
+}
@@ -0,0 +1,44 @@
+package gotimeout
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAddCallbackDuringTrigger(t *testing.T) {
+	to := MustNewTimeout()
+	te := to.arm(to.newEntry(time.Hour))
+	const n = 200
+	var runs [3 * n]atomic.Int32
+	add := func(i int) {
+		te.AddCallback(func() { runs[i].Add(1) })
+	}
+	//the first callback appends to the entry while trigger runs the callbacks it took
+	te.AddCallback(func() {
+		for i := n; i < 2*n; i++ {
+			add(i)
+		}
+	})
+	for i := 0; i < n; i++ {
+		add(i)
+	}
+	//and a goroutine appends concurrently, its callbacks land on either side of the snapshot
+	var appender sync.WaitGroup
+	appender.Add(1)
+	go func() {
+		defer appender.Done()
+		for i := 2 * n; i < 3*n; i++ {
+			add(i)
+		}
+	}()
+	to.fire(te)
+	appender.Wait()
+	<-to.Idle()
+	for i := range runs {
+		if got := runs[i].Load(); got != 1 {
+			t.Fatalf("callback %d ran %d times", i, got)
+		}
+	}
+}
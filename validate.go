@@ -0,0 +1,76 @@
+package gotimeout
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate checks the configuration of the Timeout and returns every problem it finds, joined into one error
+// each of them wraps ErrInvalidConfig, NewTimeout calls it, so only a Timeout built by hand needs to call it itself
+// zero keeps the default for every setting, a negative length or count, or an unknown enum value, is not valid
+// neither is a MaxSeconds shorter than Granularity, as it would leave no length to cache, nor a wheel tick that is not positive
+// or a fine wheel horizon shorter than its tick
+func (t *Timeout) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidConfig}, args...)...))
+	}
+	if t.CacheWindow < 0 {
+		invalid("negative CacheWindow %v", t.CacheWindow)
+	}
+	if t.MaxEntryAge < 0 {
+		invalid("negative MaxEntryAge %v", t.MaxEntryAge)
+	}
+	if t.Granularity < 0 {
+		invalid("negative Granularity %v", t.Granularity)
+	}
+	if t.MaxSeconds < 0 {
+		invalid("negative MaxSeconds %d", t.MaxSeconds)
+	} else if t.MaxSeconds > 0 && secondsToDuration(t.MaxSeconds) < t.granularity() {
+		invalid("MaxSeconds %d is shorter than Granularity %v", t.MaxSeconds, t.granularity())
+	}
+	if t.wheel != nil && t.wheel.tick <= 0 {
+		invalid("timing wheel tick %v is not positive", t.wheel.tick)
+	}
+	if t.fine != nil {
+		if t.fine.tick <= 0 {
+			invalid("fine wheel tick %v is not positive", t.fine.tick)
+		} else if t.fine.horizon < t.fine.tick {
+			invalid("fine wheel horizon %v is shorter than its tick %v", t.fine.horizon, t.fine.tick)
+		}
+	}
+	if t.Jitter < 0 {
+		invalid("negative Jitter %v", t.Jitter)
+	}
+	if t.CallbackTimeout < 0 {
+		invalid("negative CallbackTimeout %v", t.CallbackTimeout)
+	}
+	if t.Workers < 0 {
+		invalid("negative Workers %d", t.Workers)
+	}
+	if t.MaxPendingCallbacks < 0 {
+		invalid("negative MaxPendingCallbacks %d", t.MaxPendingCallbacks)
+	}
+	if t.Coalesce < 0 {
+		invalid("negative Coalesce %d", t.Coalesce)
+	}
+	if t.MaxCallbacksPerBucket < 0 {
+		invalid("negative MaxCallbacksPerBucket %d", t.MaxCallbacksPerBucket)
+	}
+	if t.MaxLongEntries < 0 {
+		invalid("negative MaxLongEntries %d", t.MaxLongEntries)
+	}
+	if t.ExecutionOrder < FIFO || t.ExecutionOrder > LIFO {
+		invalid("unknown ExecutionOrder %d", t.ExecutionOrder)
+	}
+	if t.Fallback < FallbackCoalesce || t.Fallback > FallbackError {
+		invalid("unknown Fallback %d", t.Fallback)
+	}
+	if t.PanicPolicy < PanicRecover || t.PanicPolicy > PanicPropagate {
+		invalid("unknown PanicPolicy %d", t.PanicPolicy)
+	}
+	if t.Rounding < RoundNearest || t.Rounding > RoundCeil {
+		invalid("unknown Rounding %d", t.Rounding)
+	}
+	return errors.Join(errs...)
+}
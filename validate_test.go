@@ -0,0 +1,47 @@
+package gotimeout_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asynkron/gotimeout"
+)
+
+func TestNewTimeoutValidates(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []gotimeout.Option
+		want []string
+	}{
+		{"negative window", []gotimeout.Option{gotimeout.WithCacheWindow(-time.Second)}, []string{"CacheWindow"}},
+		{"negative granularity", []gotimeout.Option{gotimeout.WithGranularity(-time.Millisecond)}, []string{"Granularity"}},
+		{"max below granularity", []gotimeout.Option{gotimeout.WithMaxSeconds(1), gotimeout.WithGranularity(2 * time.Second)}, []string{"MaxSeconds 1 is shorter"}},
+		{"several", []gotimeout.Option{gotimeout.WithWorkers(-1), gotimeout.WithFallback(gotimeout.Fallback(9))}, []string{"Workers", "Fallback"}},
+		{"unknown rounding", []gotimeout.Option{gotimeout.WithRounding(gotimeout.RoundingMode(3))}, []string{"Rounding"}},
+		{"zero wheel tick", []gotimeout.Option{gotimeout.WithTimingWheel(0)}, []string{"timing wheel tick"}},
+		{"negative fine tick", []gotimeout.Option{gotimeout.WithFineWheel(-time.Millisecond, time.Second)}, []string{"fine wheel tick"}},
+		{"fine horizon below tick", []gotimeout.Option{gotimeout.WithFineWheel(10*time.Millisecond, time.Millisecond)}, []string{"fine wheel horizon"}},
+	} {
+		to, err := gotimeout.NewTimeout(tc.opts...)
+		if to != nil || !errors.Is(err, gotimeout.ErrInvalidConfig) {
+			t.Fatalf("%s: NewTimeout = %v, %v, want ErrInvalidConfig", tc.name, to, err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Fatalf("%s: error %q does not mention %s", tc.name, err, want)
+			}
+		}
+	}
+
+	if _, err := gotimeout.NewTimeout(gotimeout.WithMaxSeconds(1), gotimeout.WithGranularity(time.Second)); err != nil {
+		t.Fatalf("NewTimeout with a single cached second: %v", err)
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("MustNewTimeout did not panic for an invalid option")
+		}
+	}()
+	gotimeout.MustNewTimeout(gotimeout.WithJitter(-time.Second))
+}
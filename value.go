@@ -0,0 +1,17 @@
+package gotimeout
+
+// AfterValue works like After, but sends the result of fn once the timeout fires
+// the channel is buffered, so fn never blocks the entry, a result nobody receives is collected with the channel
+// if fn panics nothing is sent, the panic is handled like that of any other callback
+func AfterValue[T any](seconds int, fn func() T) <-chan T {
+	return AfterValueOn(defaultTimeout(), seconds, fn)
+}
+
+// AfterValueOn is AfterValue on the given Timeout, methods cannot have type parameters
+func AfterValueOn[T any](t *Timeout, seconds int, fn func() T) <-chan T {
+	c := make(chan T, 1)
+	t.schedule(secondsToDuration(seconds), func() {
+		c <- fn()
+	})
+	return c
+}
@@ -0,0 +1,77 @@
+package gotimeout
+
+import (
+	"sync"
+	"time"
+)
+
+//pending counts armed entries and callbacks that are about to run
+//unlike a sync.WaitGroup it can be waited on while new work keeps getting added
+type pending struct {
+	mu    sync.Mutex
+	count int
+	idle  chan struct{} //closed when count drops to zero
+}
+
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+func (p *pending) add() {
+	p.mu.Lock()
+	p.count++
+	p.mu.Unlock()
+}
+
+func (p *pending) done() {
+	p.mu.Lock()
+	p.count--
+	if p.count == 0 && p.idle != nil {
+		close(p.idle)
+		p.idle = nil
+	}
+	p.mu.Unlock()
+}
+
+//wait returns a channel that is closed once nothing is pending
+func (p *pending) wait() <-chan struct{} {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.count == 0 {
+		return closedChan
+	}
+	if p.idle == nil {
+		p.idle = make(chan struct{})
+	}
+	return p.idle
+}
+
+// Wait blocks until every entry armed so far has fired and its callbacks have returned
+// entries cancelled by Stop count as done, so Wait returns right after Stop
+func (t *Timeout) Wait() {
+	<-t.pending.wait()
+}
+
+// Idle returns a channel that is closed once no entry is armed and no callback is running, right away if that is the case already
+// the channel is closed once and stays closed, once the Timeout is busy again Idle returns a fresh channel for the next idle transition
+// so call Idle again for every cycle rather than holding on to the channel
+func (t *Timeout) Idle() <-chan struct{} {
+	return t.pending.wait()
+}
+
+// WaitTimeout works like Wait, but gives up after d
+// it returns false if callbacks were still pending when d elapsed
+func (t *Timeout) WaitTimeout(d time.Duration) bool {
+	expired := make(chan struct{})
+	timer := t.timer(d, func() { close(expired) })
+	defer timer.Stop()
+
+	select {
+	case <-t.pending.wait():
+		return true
+	case <-expired:
+		return false
+	}
+}
@@ -0,0 +1,35 @@
+package gotimeout_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIdle(t *testing.T) {
+	to, clock := newFakeTimeout()
+	waitFor(t, to.Idle(), "Idle of an unused Timeout")
+
+	for cycle := 0; cycle < 3; cycle++ {
+		to.AfterFunc(1, func() {})
+		idle := to.Idle()
+		select {
+		case <-idle:
+			t.Fatalf("cycle %d: Idle closed while an entry is armed", cycle)
+		default:
+		}
+		clock.Advance(time.Second)
+		waitFor(t, idle, "Idle after the entry fired")
+	}
+
+	//a running callback keeps the Timeout busy until it returns
+	release := make(chan struct{})
+	to.AfterFunc(0, func() { <-release })
+	idle := to.Idle()
+	select {
+	case <-idle:
+		t.Fatal("Idle closed while a callback is running")
+	default:
+	}
+	close(release)
+	waitFor(t, idle, "Idle after the callback returned")
+}
@@ -0,0 +1,148 @@
+package gotimeout
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelLevels = 4 //64^4 ticks, about 19 days with a 100ms tick, longer timers are cascaded again
+)
+
+const (
+	wheelTimerPending int32 = iota
+	wheelTimerFired
+	wheelTimerStopped
+)
+
+type wheelTimer struct {
+	deadline uint64 //tick at which the timer fires
+	f        func()
+	state    atomic.Int32
+}
+
+// Stop prevents the timer from firing, it returns false if the timer already fired or was stopped
+// a stopped timer stays in its slot until the wheel reaches it, it is just skipped then
+func (wt *wheelTimer) Stop() bool {
+	return wt.state.CompareAndSwap(wheelTimerPending, wheelTimerStopped)
+}
+
+// wheel is a hierarchical timing wheel, a single ticker drives every timer armed through it
+// level 0 has one slot per tick, every higher level has one slot per full turn of the level below
+// when a level wraps around, the matching slot of the level above is cascaded down
+type wheel struct {
+	mu      sync.Mutex
+	tick    time.Duration
+	start   time.Time
+	current uint64
+	levels  [wheelLevels][wheelSlots][]*wheelTimer
+	done    chan struct{}
+}
+
+//newWheel takes the tick as given, Validate rejects one that is not positive
+func newWheel(tick time.Duration) *wheel {
+	return &wheel{
+		tick: tick,
+	}
+}
+
+// AfterFunc arms f to run after d, rounded up to the next tick
+func (w *wheel) AfterFunc(d time.Duration, f func()) Timer {
+	//rounded up without adding a tick first, which would overflow for the longest durations
+	ticks := uint64(d / w.tick)
+	if d%w.tick != 0 {
+		ticks++
+	}
+	if ticks == 0 {
+		ticks = 1
+	}
+	wt := &wheelTimer{f: f}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done == nil {
+		//first timer, start the ticker
+		w.start = time.Now()
+		w.done = make(chan struct{})
+		go w.run(w.done)
+	}
+	wt.deadline = w.current + ticks
+	w.place(wt)
+	return wt
+}
+
+//place puts the timer in the lowest level that can hold its remaining ticks
+func (w *wheel) place(wt *wheelTimer) {
+	delta := wt.deadline - w.current
+	for level := 0; level < wheelLevels; level++ {
+		if delta < 1<<(wheelBits*(level+1)) || level == wheelLevels-1 {
+			slot := (wt.deadline >> (wheelBits * level)) & (wheelSlots - 1)
+			w.levels[level][slot] = append(w.levels[level][slot], wt)
+			return
+		}
+	}
+}
+
+//advance moves the wheel one tick forward and returns the timers that are due
+func (w *wheel) advance() []*wheelTimer {
+	w.current++
+	for level := 1; level < wheelLevels; level++ {
+		if w.current&(1<<(wheelBits*level)-1) != 0 {
+			break
+		}
+		slot := (w.current >> (wheelBits * level)) & (wheelSlots - 1)
+		timers := w.levels[level][slot]
+		w.levels[level][slot] = nil
+		for _, wt := range timers {
+			if wt.state.Load() == wheelTimerPending {
+				w.place(wt)
+			}
+		}
+	}
+	slot := w.current & (wheelSlots - 1)
+	due := w.levels[0][slot]
+	w.levels[0][slot] = nil
+	return due
+}
+
+func (w *wheel) run(done chan struct{}) {
+	ticker := time.NewTicker(w.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.mu.Lock()
+			//catch up on ticks missed while the goroutine was not scheduled
+			target := uint64(time.Since(w.start) / w.tick)
+			var due []*wheelTimer
+			for w.current < target {
+				due = append(due, w.advance()...)
+			}
+			w.mu.Unlock()
+
+			for _, wt := range due {
+				if wt.state.CompareAndSwap(wheelTimerPending, wheelTimerFired) {
+					//each entry fires on its own goroutine so a slow batch never stalls the wheel
+					go wt.f()
+				}
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+//stop shuts down the ticker, timers still in the wheel never fire
+func (w *wheel) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.done != nil {
+		close(w.done)
+	}
+	w.done = nil
+	w.current = 0
+	w.levels = [wheelLevels][wheelSlots][]*wheelTimer{}
+}